@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/BurntSushi/toml"
+)
+
+// Settings are read from a TOML config file, then environment variables & finally flags, each overriding the last.
+// They share names, e.g. the "notify-level" key is the ROSSLYN_NOTIFY_LEVEL variable & the -notify-level flag, so are
+// all validated the same way. Key bindings can only be set in the file's [keys] table.
+const (
+	configFlag = "config"
+	envPrefix  = "ROSSLYN_"
+	keysTable  = "keys"
+)
+
+// Returns the environment variable for a setting, e.g. ROSSLYN_NOTIFY_LEVEL
+func envName(setting string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(setting, "-", "_", -1))
+}
+
+// Sets each flag not given on the command line from the environment or the config file, returning the key bindings
+// from the file. A missing file is only an error if required.
+func applySettings(fs *flag.FlagSet, path string, required bool, lookupEnv func(string) (string, bool)) (map[string][]string, error) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	// Config file
+	var settings map[string]interface{}
+	_, err := toml.DecodeFile(path, &settings)
+	switch {
+	case os.IsNotExist(err) && !required:
+		settings = nil
+	case os.IsNotExist(err):
+		return nil, err
+	case err != nil:
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Report errors consistently
+	var keys map[string][]string
+	for _, name := range names {
+		if name == keysTable {
+			if keys, err = keyBindings(settings[name]); err != nil {
+				return nil, fmt.Errorf("%v: [%v] %v", path, keysTable, err)
+			}
+			continue
+		}
+		if fs.Lookup(name) == nil || name == configFlag {
+			return nil, fmt.Errorf("%v: unknown setting '%v'", path, name)
+		}
+		if given[name] {
+			continue
+		}
+		s, err := settingString(settings[name])
+		if err == nil {
+			err = fs.Set(name, s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v: %v", path, name, err)
+		}
+	}
+
+	// Environment
+	err = nil
+	fs.VisitAll(func(f *flag.Flag) {
+		if s, ok := lookupEnv(envName(f.Name)); ok && !given[f.Name] && f.Name != configFlag && err == nil {
+			if e := fs.Set(f.Name, s); e != nil {
+				err = fmt.Errorf("%v: %v", envName(f.Name), e)
+			}
+		}
+	})
+	return keys, err
+}
+
+// Converts a value from the config file to the form its flag accepts. Arrays & tables become comma separated lists,
+// e.g. { general = "muted" } is "general=muted".
+func settingString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := settingString(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		var items []string
+		for k, item := range v {
+			s, err := settingString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, k + "=" + s)
+		}
+		sort.Strings(items)
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// Reads the [keys] table, where each action has keys or a list of them, e.g. split-pane = ["ctrl-w v", "ctrl-x 3"]
+func keyBindings(v interface{}) (map[string][]string, error) {
+	table, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a table")
+	}
+	keys := make(map[string][]string)
+	for action, v := range table {
+		switch v := v.(type) {
+		case string:
+			keys[action] = []string{v}
+		case []interface{}:
+			keys[action] = []string{}
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%v: keys must be strings", action)
+				}
+				keys[action] = append(keys[action], s)
+			}
+		default:
+			return nil, fmt.Errorf("%v: keys must be a string or list of strings", action)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"github.com/g-dx/rosslyn/ui"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "rosslyn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "config.toml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplySettings(t *testing.T) {
+	path := writeConfig(t, `
+history-size = 100
+keywords = ["deploy", "outage"]
+notify-level = "all"
+time-format = "15:04"
+
+[notify-channels]
+general = "muted"
+alerts = "all"
+
+[keys]
+split-pane = "ctrl-x 3"
+quit = ["ctrl-q", "ctrl-x ctrl-c"]
+`)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(configFlag, "", "")
+	history := fs.Int("history-size", 50, "")
+	keywords := fs.String("keywords", "", "")
+	level := fs.String("notify-level", "mentions", "")
+	channels := fs.String("notify-channels", "", "")
+	format := fs.String("time-format", "3:04 PM", "")
+	idle := fs.String("idle", "", "")
+	if err := fs.Parse([]string{"-notify-level", "muted"}); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"ROSSLYN_TIME_FORMAT": "15:04:05", "ROSSLYN_NOTIFY_LEVEL": "all"}
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	keys, err := applySettings(fs, path, true, lookupEnv)
+	if err != nil {
+		t.Fatalf("Got : %v\nWant: nil", err)
+	}
+	got := []interface{}{*history, *keywords, *level, *channels, *format, *idle}
+	want := []interface{}{100, "deploy,outage", "muted", "alerts=all,general=muted", "15:04:05", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got : %v\nWant: %v", got, want)
+	}
+	wantKeys := map[string][]string{"split-pane": {"ctrl-x 3"}, "quit": {"ctrl-q", "ctrl-x ctrl-c"}}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("Got : %v\nWant: %v", keys, wantKeys)
+	}
+}
+
+func TestApplySettingsErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		env    string
+		want   string
+	}{
+		{`nonsense = 1`, "", "unknown setting 'nonsense'"},
+		{`config = "other.toml"`, "", "unknown setting 'config'"},
+		{`history-size = "lots"`, "", "history-size: parse error"},
+		{`history-size = `, "", "config.toml"},
+		{`[keys]
+quit = 1`, "", "[keys] quit"},
+		{``, "lots", "ROSSLYN_HISTORY_SIZE: parse error"},
+	}
+	for _, test := range tests {
+		path := writeConfig(t, test.config)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String(configFlag, "", "")
+		fs.Int("history-size", 50, "")
+		lookupEnv := func(name string) (string, bool) {
+			return test.env, name == "ROSSLYN_HISTORY_SIZE" && test.env != ""
+		}
+		_, err := applySettings(fs, path, true, lookupEnv)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Got : %v\nWant: error containing '%v'", err, test.want)
+		}
+	}
+
+	// Only a required file must exist
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := applySettings(fs, "/nonexistent/config.toml", false, os.LookupEnv); err != nil {
+		t.Errorf("Got : %v\nWant: nil", err)
+	}
+	if _, err := applySettings(fs, "/nonexistent/config.toml", true, os.LookupEnv); err == nil {
+		t.Errorf("Got : nil\nWant: error")
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		history, width int
+		format         string
+		ok             bool
+	}{
+		{50, 28, "3:04 PM", true},
+		{1000, 0, "15:04", true},
+		{0, 28, "15:04", false},
+		{1001, 28, "15:04", false},
+		{50, -1, "15:04", false},
+		{50, 28, "time", false},
+	}
+	for _, test := range tests {
+		if err := validateSettings(test.history, test.width, test.format); (err == nil) != test.ok {
+			t.Errorf("Got : %v\nWant: ok = %v for %v", err, test.ok, test)
+		}
+	}
+}
+
+func TestReadTokens(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"xoxp-1\n", []string{"xoxp-1"}},
+		{"xoxp-1\nxoxp-2\n", []string{"xoxp-1", "xoxp-2"}},
+		{"  xoxp-1 \r\n\n\txoxp-2", []string{"xoxp-1", "xoxp-2"}},
+		{"\n \n", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got, err := readTokens(writeConfig(t, test.content))
+		if !reflect.DeepEqual(got, test.want) || (err == nil) != (test.want != nil) {
+			t.Errorf("readTokens(%q)\nGot : %q, %v\nWant: %q", test.content, got, err, test.want)
+		}
+	}
+	if _, err := readTokens("/nonexistent/api-token"); err == nil {
+		t.Errorf("Got : nil\nWant: error")
+	}
+}
+
+func TestParseNotifyChannels(t *testing.T) {
+	cfg, err := parseNotifyConfig("mentions", "#general=muted,@bob=all,acme/#alerts=all,acme/@carol=muted", "", "")
+	if err != nil {
+		t.Fatalf("Got : %v\nWant: nil", err)
+	}
+	want := map[string]ui.NotifyLevel{"general": ui.NotifyMuted, "bob": ui.NotifyAll, "acme/alerts": ui.NotifyAll, "acme/carol": ui.NotifyMuted}
+	if !reflect.DeepEqual(cfg.Channels, want) {
+		t.Errorf("Got : %v\nWant: %v", cfg.Channels, want)
+	}
+}
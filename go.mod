@@ -0,0 +1,17 @@
+module github.com/g-dx/rosslyn
+
+go 1.23.0
+
+require (
+	github.com/0xAX/notificator v0.0.0-20220220101646-ee9b8921e557
+	github.com/BurntSushi/toml v1.6.0
+	github.com/gorilla/websocket v1.5.3
+	github.com/kyokomi/emoji/v2 v2.2.14
+	github.com/mattn/go-runewidth v0.0.30
+	github.com/nsf/termbox-go v1.1.2
+)
+
+require (
+	github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+)
@@ -3,25 +3,81 @@ package main
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"github.com/g-dx/rosslyn/slack"
 	"log"
 	"github.com/g-dx/rosslyn/ui"
 	"github.com/nsf/termbox-go"
 	"runtime/debug"
+	"flag"
+	"strings"
+	"fmt"
+	"time"
 )
 
 func main() {
 
-	// Setup logging
-	f, err := os.Create(os.ExpandEnv("${HOME}/.rosslyn/app.log"))
-	if err != nil {
-		panic(err)
+	defaultConfig, ok := os.LookupEnv(envName(configFlag))
+	if !ok {
+		defaultConfig = "${HOME}/.rosslyn/config.toml"
+	}
+	config := flag.String(configFlag, defaultConfig, "TOML file of settings, named as these flags")
+	dataDir := flag.String("data-dir", "${HOME}/.rosslyn", "directory of the api-token & stars files")
+	logDir := flag.String("log-dir", "", "directory to write logs to (default data-dir)")
+	defaultChannel := flag.String("default-channel", "", "name of the channel to start in (default the first channel)")
+	historySize := flag.Int("history-size", ui.DefaultHistorySize, "number of messages to load at a time")
+	timeFormat := flag.String("time-format", ui.DefaultTimeFormat, "layout of message times, see https://pkg.go.dev/time#pkg-constants")
+	idle := flag.Duration("idle", 0, "mark yourself away after this period of inactivity (e.g. 10m)")
+	keywords := flag.String("keywords", "", "comma separated list of words to highlight")
+	notifyLevel := flag.String("notify-level", "mentions", "default notification level: all, mentions or muted")
+	notifyChannels := flag.String("notify-channels", "", "per channel & DM notification levels, optionally for one team (e.g. general=muted,@bob=all,acme/alerts=all)")
+	notifyKeywords := flag.String("notify-keywords", "", "comma separated list of words which always notify")
+	quietHours := flag.String("quiet-hours", "", "suppress notifications during these hours (e.g. 22:00-07:30)")
+	throttle := flag.Duration("notify-throttle", 10*time.Second, "minimum time between notifications for a channel")
+	sidebarWidth := flag.Int("sidebar-width", 28, "initial width of the sidebar in columns")
+	sidebarSort := flag.String("sidebar-sort", "name", "order of channels in the sidebar: name, recent or unread")
+	notifier := flag.String("notifier", ui.NotifierAuto, "notification backend: auto, desktop, bell, osc9, osc777 or tmux")
+	slackCommands := flag.String("slack-commands", "", "comma separated list of integration commands to pass to Slack (e.g. /giphy,/poll)")
+	flag.Parse()
+
+	// Settings not given as flags come from the environment or config file
+	configGiven := ok
+	flag.Visit(func(f *flag.Flag) { configGiven = configGiven || f.Name == configFlag })
+	keys, err := applySettings(flag.CommandLine, os.ExpandEnv(*config), configGiven, os.LookupEnv)
+	check(err)
+
+	notify, err := parseNotifyConfig(*notifyLevel, *notifyChannels, *notifyKeywords, *quietHours)
+	check(err)
+	notify.Throttle = *throttle
+	n, err := ui.NewNotifier(*notifier)
+	check(err)
+	order, err := ui.ParseSortOrder(*sidebarSort)
+	check(err)
+	keymap, err := ui.NewKeymap(keys)
+	check(err)
+	check(validateSettings(*historySize, *sidebarWidth, *timeFormat))
+
+	// Create directories
+	*dataDir = os.ExpandEnv(*dataDir)
+	*logDir = os.ExpandEnv(*logDir)
+	if *logDir == "" {
+		*logDir = *dataDir
 	}
+	check(os.MkdirAll(*dataDir, 0700))
+	check(os.MkdirAll(*logDir, 0700))
+
+	// Setup logging
+	f, err := os.Create(filepath.Join(*logDir, "app.log"))
+	check(err)
 	logger := log.New(f, "", log.Ldate | log.Ltime)
+	check(ui.OpenLogs(*logDir))
+	check(slack.OpenLog(*logDir))
 
-	token, err := ioutil.ReadFile(os.ExpandEnv("${HOME}/.rosslyn/api-token"))
-	if err != nil {
-		panic(err)
+	tokens, err := readTokens(filepath.Join(*dataDir, "api-token"))
+	check(err)
+	var apis []slack.Apis
+	for _, token := range tokens {
+		apis = append(apis, slack.NewApis([]byte(token)))
 	}
 
 	//
@@ -49,6 +105,87 @@ func main() {
 			panic(err)
 		}
 	}()
-	ctrl := ui.NewController(logger, slack.NewApis(token))
+	cfg := ui.Config{
+		IdleTimeout:    *idle,
+		Keywords:       strings.Split(*keywords, ","),
+		Notify:         notify,
+		SidebarSort:    order,
+		SidebarWidth:   *sidebarWidth,
+		StarsFile:      filepath.Join(*dataDir, "stars"),
+		Keymap:         keymap,
+		DefaultChannel: *defaultChannel,
+		HistorySize:    *historySize,
+		TimeFormat:     *timeFormat,
+		SlackCommands:  strings.Split(*slackCommands, ","),
+	}
+	ctrl := ui.NewController(logger, apis, cfg, n)
 	ctrl.Run()
-}
\ No newline at end of file
+}
+
+// Prints the error & exits, if there is one
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+// Reads the API tokens, one per line & one line per team
+func readTokens(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := strings.Fields(string(data))
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no API tokens found in %v", path)
+	}
+	return tokens, nil
+}
+
+// Checks settings which aren't otherwise parsed
+func validateSettings(historySize, sidebarWidth int, timeFormat string) error {
+	if historySize < 1 || historySize > 1000 {
+		return fmt.Errorf("history-size must be between 1 & 1000, not %v", historySize)
+	}
+	if sidebarWidth < 0 {
+		return fmt.Errorf("sidebar-width can't be negative")
+	}
+	if t := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC); t.Format(timeFormat) == timeFormat {
+		return fmt.Errorf("time-format '%v' doesn't contain a time, e.g. 15:04", timeFormat)
+	}
+	return nil
+}
+
+func parseNotifyConfig(level, channels, keywords, quiet string) (cfg ui.NotifyConfig, err error) {
+	if cfg.Level, err = ui.ParseNotifyLevel(level); err != nil {
+		return
+	}
+	if cfg.Quiet, err = ui.ParseQuietHours(quiet); err != nil {
+		return
+	}
+	cfg.Keywords = strings.Split(keywords, ",")
+	cfg.Channels = make(map[string]ui.NotifyLevel)
+	for _, entry := range strings.Split(channels, ",") {
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return cfg, fmt.Errorf("invalid channel notification level '%v' (want name=level)", entry)
+		}
+		if cfg.Channels[notifyChannelName(kv[0])], err = ui.ParseNotifyLevel(kv[1]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Normalises "[team/]#channel" or "[team/]@user" to the name the rules look up
+func notifyChannelName(s string) string {
+	team, name := "", s
+	if i := strings.LastIndex(s, "/"); i != -1 {
+		team, name = s[:i+1], s[i+1:]
+	}
+	return team + strings.TrimLeft(name, "#@")
+}
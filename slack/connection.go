@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"strconv"
 	"os"
+	"path/filepath"
 	"strings"
 	"sort"
 )
@@ -20,25 +21,44 @@ const (
 	apiUrl = "https://slack.com/api/"
 )
 
-var debug *log.Logger
+var debug = log.New(ioutil.Discard, "", 0)
 
-func init() {
-	f, err := os.Create(os.ExpandEnv("${HOME}/.rosslyn/api.log"))
+// Logs API calls to api.log in the directory
+func OpenLog(dir string) error {
+	f, err := os.Create(filepath.Join(dir, "api.log"))
 	if err != nil {
-		panic(err)
+		return err
 	}
 	debug = log.New(f, "", log.Ldate | log.Ltime)
+	return nil
 }
 
 
 type Apis interface {
-	MarkChannel(id string, ts *time.Time)
+	MarkChannel(id string, ts string) error
 	GetUserList() *UserList
+	GetEmojiList() (*EmojiList, error)
 	GetChannelInfo(channel string) *ChannelInfo
-	GetChannelHistory(channel string, start time.Time) *MsgHistory
+	GetChannelHistory(channel string, start time.Time, count int) *MsgHistory
 	GetGroupInfo(channel string) *GroupInfo
 	GetGroupAndChannelList() *GroupAndChannelList
-	RtmConnect() *websocket.Conn
+	GetConversationInfo(id string) (*ConversationInfo, error)
+	GetConversationMembers(id string) ([]string, error)
+	ListPublicChannels() ([]Conversation, error)
+	OpenConversation(users []string) (string, error)
+	JoinConversation(id string) (*ConversationInfo, error)
+	LeaveConversation(id string) error
+	CreateConversation(name string, private bool) (*ConversationInfo, error)
+	ArchiveConversation(id string) error
+	InviteToConversation(id string, users []string) error
+	KickFromConversation(id, user string) error
+	SetConversationTopic(id, topic string) error
+	SetConversationPurpose(id, purpose string) error
+	GetPresence() (string, error)
+	SetPresence(presence string) error
+	RunCommand(id, command, text string) error
+	SendMeMessage(id, text string) error
+	RtmConnect() (*RtmConnect, *websocket.Conn)
 }
 
 type apis struct {
@@ -47,35 +67,17 @@ type apis struct {
 
 	users     *UserList
 	grpAndChn *GroupAndChannelList
+	emoji     *EmojiList
 }
 
 func NewApis(token []byte) Apis {
 	return &apis{ token: string(token) }
 }
 
-func (api *apis) MarkChannel(id string, time *time.Time) {
-
-	method := ""
-	switch id[:1] {
-	case "C":
-		method = "channels.mark"
-	case "D":
-		method = "im.mark"
-	case "G":
-		method = "groups.mark"
-	default:
-		panic(errors.New(fmt.Sprintf("Unable to determine history API for ID: %v", id)))
-	}
-
-
-	ts := fmt.Sprintf("%v.00000", strconv.FormatInt(time.Unix(), 10))
-
-	// Load & cache users
+// Moves the read cursor of a channel to the message with the given timestamp
+func (api *apis) MarkChannel(id string, ts string) error {
 	var empty struct{}
-	err := api.call(method, map[string]string {"channel": id, "ts": ts }, &empty)
-	if err != nil {
-		panic(err)
-	}
+	return api.invoke("conversations.mark", map[string]string{"channel": id, "ts": ts}, &empty)
 }
 
 func (api *apis) GetUserList() *UserList {
@@ -97,6 +99,19 @@ func (api *apis) GetUserList() *UserList {
 	return api.users
 }
 
+// Returns the workspace's custom emoji, loading them on first use
+func (api *apis) GetEmojiList() (*EmojiList, error) {
+	if api.emoji != nil {
+		return api.emoji, nil
+	}
+	var emoji EmojiList
+	if err := api.invoke("emoji.list", map[string]string{}, &emoji); err != nil {
+		return nil, err
+	}
+	api.emoji = &emoji
+	return api.emoji, nil
+}
+
 func (api *apis) GetGroupInfo(id string) *GroupInfo {
 
 	// Load info
@@ -119,7 +134,8 @@ func (api *apis) GetChannelInfo(id string) *ChannelInfo {
 	return &info
 }
 
-func (api *apis) GetChannelHistory(id string, start time.Time) *MsgHistory {
+// Returns up to count messages before start
+func (api *apis) GetChannelHistory(id string, start time.Time, count int) *MsgHistory {
 
 	method := ""
 	switch id[:1] {
@@ -137,7 +153,7 @@ func (api *apis) GetChannelHistory(id string, start time.Time) *MsgHistory {
 
 	// Load messages
 	var history MsgHistory
-	err := api.call(method, map[string]string {"channel": id, "latest": ts, "count": "50"}, &history)
+	err := api.call(method, map[string]string {"channel": id, "latest": ts, "count": strconv.Itoa(count)}, &history)
 	if err != nil {
 		panic(err)
 	}
@@ -181,7 +197,159 @@ func (api *apis) GetGroupAndChannelList() *GroupAndChannelList {
 
 
 
-func (api *apis) RtmConnect() *websocket.Conn {
+func (api *apis) GetConversationInfo(id string) (*ConversationInfo, error) {
+	var info ConversationInfo
+	err := api.invoke("conversations.info", map[string]string{"channel": id, "include_num_members": "true"}, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (api *apis) GetConversationMembers(id string) ([]string, error) {
+
+	// Page through all members
+	var members []string
+	cursor := ""
+	for {
+		var page struct {
+			Members  []string `json:"members"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		err := api.invoke("conversations.members", map[string]string{"channel": id, "cursor": cursor}, &page)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page.Members...)
+		if page.Metadata.NextCursor == "" {
+			return members, nil
+		}
+		cursor = page.Metadata.NextCursor
+	}
+}
+
+// Lists the public channels which aren't archived, unlike GetGroupAndChannelList this is never cached so includes
+// channels created since connecting
+func (api *apis) ListPublicChannels() ([]Conversation, error) {
+
+	// Page through all channels
+	var chls []Conversation
+	cursor := ""
+	for {
+		var page struct {
+			Channels []Conversation `json:"channels"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		params := map[string]string{"types": "public_channel", "exclude_archived": "true", "limit": "200", "cursor": cursor}
+		err := api.invoke("conversations.list", params, &page)
+		if err != nil {
+			return nil, err
+		}
+		chls = append(chls, page.Channels...)
+		if page.Metadata.NextCursor == "" {
+			return chls, nil
+		}
+		cursor = page.Metadata.NextCursor
+	}
+}
+
+// Opens (or resumes) an IM with a single user or an MPIM with several & returns its ID
+func (api *apis) OpenConversation(users []string) (string, error) {
+	var open struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	err := api.invoke("conversations.open", map[string]string{"users": strings.Join(users, ",")}, &open)
+	if err != nil {
+		return "", err
+	}
+	return open.Channel.ID, nil
+}
+
+func (api *apis) JoinConversation(id string) (*ConversationInfo, error) {
+	var info ConversationInfo
+	err := api.invoke("conversations.join", map[string]string{"channel": id}, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (api *apis) LeaveConversation(id string) error {
+	var empty struct{}
+	return api.invoke("conversations.leave", map[string]string{"channel": id}, &empty)
+}
+
+func (api *apis) CreateConversation(name string, private bool) (*ConversationInfo, error) {
+	var info ConversationInfo
+	err := api.invoke("conversations.create", map[string]string{"name": name, "is_private": strconv.FormatBool(private)}, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (api *apis) ArchiveConversation(id string) error {
+	var empty struct{}
+	return api.invoke("conversations.archive", map[string]string{"channel": id}, &empty)
+}
+
+func (api *apis) InviteToConversation(id string, users []string) error {
+	var empty struct{}
+	return api.invoke("conversations.invite", map[string]string{"channel": id, "users": strings.Join(users, ",")}, &empty)
+}
+
+func (api *apis) KickFromConversation(id, user string) error {
+	var empty struct{}
+	return api.invoke("conversations.kick", map[string]string{"channel": id, "user": user}, &empty)
+}
+
+func (api *apis) SetConversationTopic(id, topic string) error {
+	var empty struct{}
+	return api.invoke("conversations.setTopic", map[string]string{"channel": id, "topic": topic}, &empty)
+}
+
+func (api *apis) SetConversationPurpose(id, purpose string) error {
+	var empty struct{}
+	return api.invoke("conversations.setPurpose", map[string]string{"channel": id, "purpose": purpose}, &empty)
+}
+
+// Runs a slash command (e.g. "/remind") in the channel as if typed into the official client
+func (api *apis) RunCommand(id, command, text string) error {
+	var empty struct{}
+	return api.invoke("chat.command", map[string]string{"channel": id, "command": command, "text": text}, &empty)
+}
+
+// Sends an action message (e.g. "/me waves") to the channel
+func (api *apis) SendMeMessage(id, text string) error {
+	var empty struct{}
+	return api.invoke("chat.meMessage", map[string]string{"channel": id, "text": text}, &empty)
+}
+
+// Returns the presence ("active" or "away") of the authenticated user
+func (api *apis) GetPresence() (string, error) {
+	var presence struct {
+		Presence string `json:"presence"`
+	}
+	err := api.invoke("users.getPresence", map[string]string{}, &presence)
+	if err != nil {
+		return "", err
+	}
+	return presence.Presence, nil
+}
+
+// Sets the presence of the authenticated user to either "auto" or "away"
+func (api *apis) SetPresence(presence string) error {
+	var empty struct{}
+	return api.invoke("users.setPresence", map[string]string{"presence": presence}, &empty)
+}
+
+func (api *apis) RtmConnect() (*RtmConnect, *websocket.Conn) {
 
 	// Connect
 	var connect RtmConnect
@@ -195,11 +363,59 @@ func (api *apis) RtmConnect() *websocket.Conn {
 	if err != nil {
 		panic(err)
 	}
-	return conn
+	return &connect, conn
+}
+
+// Error returned by Slack when a call is not "ok"
+type ApiError struct {
+	Method string
+	Err    string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("%v failed: %v", e.Method, e.Err)
+}
+
+// Like call(...) but checks the "ok" field of the response and returns an *ApiError if it is not set
+func (api *apis) invoke(method string, params map[string]string, i interface{}) error {
+
+	data, err := api.fetch(method, params)
+	if err != nil {
+		return err
+	}
+
+	// Check status
+	var status struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(data, &status)
+	if err != nil {
+		return err
+	}
+	if !status.Ok {
+		return &ApiError{method, status.Error}
+	}
+	return json.Unmarshal(data, i)
 }
 
 func (api *apis) call(method string, params map[string]string, i interface{}) error {
 
+	data, err := api.fetch(method, params)
+	if err != nil {
+		return err
+	}
+
+	// Unmarshall
+	err = json.Unmarshal(data, i)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (api *apis) fetch(method string, params map[string]string) ([]byte, error) {
+
 	// Add token
 	params["token"] = api.token
 
@@ -214,22 +430,12 @@ func (api *apis) call(method string, params map[string]string, i interface{}) er
 	debug.Println(strings.Replace(apiCall, api.token, "<removed>", 1))
 	resp, err := http.Get(apiCall)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// Read body
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	// Unmarshall
-	err = json.Unmarshal(data, i)
-	if err != nil {
-		return err
-	}
-	return nil
+	return ioutil.ReadAll(resp.Body)
 }
 
 type RtmConnection struct {
@@ -374,6 +580,10 @@ func (c *RtmConnection) unmarshalEvent(data []byte) Event {
 		var msg PresenceChange
 		c.unmarshal(data, &msg)
 		return &msg
+	case manual_presence_change:
+		var msg ManualPresenceChange
+		c.unmarshal(data, &msg)
+		return &msg
 	default:
 		return &e // Can't do anything else with this
 	}
@@ -393,6 +603,11 @@ func (c *RtmConnection) unmarshalMessage(sType MsgSubType, data []byte) Event {
 		var msg MessageThreadReply
 		c.unmarshal(data, &msg)
 		return &msg
+	case channel_topic, channel_purpose, group_topic, group_purpose:
+		var msg TopicChanged
+		c.unmarshal(data, &msg)
+		msg.Subtype = sType
+		return &msg
 	case none:
 		fallthrough
 	default:
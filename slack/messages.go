@@ -3,6 +3,7 @@ package slack
 import (
 	"time"
 	"sort"
+	"strings"
 )
 
 var id uint = uint(time.Now().Unix()) // Should ensure we don't overlap on application restarts
@@ -12,12 +13,13 @@ var id uint = uint(time.Now().Unix()) // Should ensure we don't overlap on appli
 type MsgType string
 
 const (
-	hello                MsgType = "hello"
-	desktop_notification MsgType = "desktop_notification"
-	user_typing          MsgType = "user_typing"
-	message              MsgType = "message"
-	response             MsgType = "response"
-	presence_change      MsgType = "presence_change"
+	hello                  MsgType = "hello"
+	desktop_notification   MsgType = "desktop_notification"
+	user_typing            MsgType = "user_typing"
+	message                MsgType = "message"
+	response               MsgType = "response"
+	presence_change        MsgType = "presence_change"
+	manual_presence_change MsgType = "manual_presence_change"
 )
 
 // ---------------------------------------------------------------------------------------------------------------------
@@ -29,8 +31,20 @@ const (
 	deleted MsgSubType = "message_deleted"
 	replied MsgSubType = "message_replied"
 	none    MsgSubType = "<n/a>"
+
+	channel_topic   MsgSubType = "channel_topic"
+	channel_purpose MsgSubType = "channel_purpose"
+	group_topic     MsgSubType = "group_topic"
+	group_purpose   MsgSubType = "group_purpose"
+
+	me_message MsgSubType = "me_message"
 )
 
+// Returns true for "/me" action messages
+func (st MsgSubType) IsMe() bool {
+	return st == me_message
+}
+
 // ---------------------------------------------------------------------------------------------------------------------
 
 type Event interface {
@@ -68,6 +82,7 @@ type SimpleMessage struct {
 	Channel string `json:"channel"`
 	User    string `json:"user,omitempty"`
 	Text    string `json:"text"`
+	Subtype MsgSubType `json:"subtype,omitempty"`
 	Ts      string `json:"ts,omitempty"`
 	AsUser  bool   `json:"as_user"`
 	Edited  struct {
@@ -99,6 +114,7 @@ func NewSimpleMessage(channel, text string) Event {
 type MessageChanged struct {
 	Message struct {
 		Type   string `json:"type"`
+		Subtype MsgSubType `json:"subtype"`
 		User   string `json:"user"`
 		Text   string `json:"text"`
 		Edited struct {
@@ -175,6 +191,28 @@ func (em *MessageThreadReply) Type() MsgType {
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+// Sent when the topic or purpose of a channel or group is changed. The subtype says which, the other field is empty.
+type TopicChanged struct {
+	Subtype MsgSubType `json:"subtype"`
+	Channel string     `json:"channel"`
+	User    string     `json:"user"`
+	Text    string     `json:"text"`
+	Topic   string     `json:"topic"`
+	Purpose string     `json:"purpose"`
+	Ts      string     `json:"ts"`
+}
+
+func (tc *TopicChanged) Type() MsgType {
+	return message
+}
+
+// Returns true if the purpose changed, which may have been cleared, rather than the topic
+func (tc *TopicChanged) IsPurpose() bool {
+	return tc.Subtype == channel_purpose || tc.Subtype == group_purpose
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 type Response struct {
 	Ok      bool   `json:"ok"`
 	ReplyTo int    `json:"reply_to"`
@@ -211,7 +249,7 @@ type MsgHistory struct {
 		Type string `json:"type"`
 		User string `json:"user"`
 		Text string `json:"text"`
-	        Subtype string `json:"channel_join"`
+		Subtype MsgSubType `json:"subtype"`
 		Ts   string `json:"ts"`
 		Edited  struct {
 			User string `json:"user"`
@@ -235,20 +273,7 @@ type UserList struct {
 		Tz       string `json:"tz"`
 		TzLabel  string `json:"tz_label"`
 		TzOffset int    `json:"tz_offset"`
-		Profile  struct {
-			AvatarHash         string `json:"avatar_hash"`
-			Image24            string `json:"image_24"`
-			Image32            string `json:"image_32"`
-			Image48            string `json:"image_48"`
-			Image72            string `json:"image_72"`
-			Image192           string `json:"image_192"`
-			Image512           string `json:"image_512"`
-			Image1024          string `json:"image_1024"`
-			ImageOriginal      string `json:"image_original"`
-			RealName           string `json:"real_name"`
-			RealNameNormalized string `json:"real_name_normalized"`
-			Email              string `json:"email"`
-		} `json:"profile"`
+		Profile  UserProfile `json:"profile"`
 		IsAdmin           bool   `json:"is_admin"`
 		IsOwner           bool   `json:"is_owner"`
 		IsPrimaryOwner    bool   `json:"is_primary_owner"`
@@ -261,6 +286,24 @@ type UserList struct {
 	CacheTs int `json:"cache_ts"`
 }
 
+type UserProfile struct {
+	AvatarHash         string `json:"avatar_hash"`
+	Image24            string `json:"image_24"`
+	Image32            string `json:"image_32"`
+	Image48            string `json:"image_48"`
+	Image72            string `json:"image_72"`
+	Image192           string `json:"image_192"`
+	Image512           string `json:"image_512"`
+	Image1024          string `json:"image_1024"`
+	ImageOriginal      string `json:"image_original"`
+	RealName           string `json:"real_name"`
+	RealNameNormalized string `json:"real_name_normalized"`
+	Email              string `json:"email"`
+	Title              string `json:"title"`
+	StatusText         string `json:"status_text"`
+	StatusEmoji        string `json:"status_emoji"`
+}
+
 func (ul *UserList) GetName(id string) string {
 	i := ul.find(id)
 	if i == -1 {
@@ -277,6 +320,22 @@ func (ul *UserList) GetRealName(id string) string {
 	return ul.Members[i].RealName
 }
 
+func (ul *UserList) GetProfile(id string) UserProfile {
+	i := ul.find(id)
+	if i == -1 {
+		return UserProfile{}
+	}
+	return ul.Members[i].Profile
+}
+
+func (ul *UserList) GetTzLabel(id string) string {
+	i := ul.find(id)
+	if i == -1 {
+		return ""
+	}
+	return ul.Members[i].TzLabel
+}
+
 func (ul *UserList) GetPresence(id string) string {
 	i := ul.find(id)
 	if i == -1 {
@@ -292,6 +351,25 @@ func (ul *UserList) SetPresence(id, presence string) {
 	}
 }
 
+// Returns the ID of the user with the given name or "" if they do not exist
+func (ul *UserList) FindByName(name string) string {
+	for _, m := range ul.Members {
+		if m.Name == name {
+			return m.ID
+		}
+	}
+	return ""
+}
+
+// Returns the user's offset from UTC in seconds & whether it is known
+func (ul *UserList) GetTzOffset(id string) (int, bool) {
+	i := ul.find(id)
+	if i == -1 || ul.Members[i].Tz == "" {
+		return 0, false
+	}
+	return ul.Members[i].TzOffset, true
+}
+
 func (ul *UserList) IsActive(id string) bool {
 	i := ul.find(id)
 	if i == -1 {
@@ -363,12 +441,45 @@ type ChannelList struct {
 	} `json:"channels"`
 }
 
+// Custom emoji of the workspace, mapping names to image URLs or "alias:name"
+type EmojiList struct {
+	Emoji map[string]string `json:"emoji"`
+}
+
+// Follows aliases from name, returning the final name & whether it is a custom emoji. Custom emoji may alias standard
+// ones, in which case the standard name is returned.
+func (el *EmojiList) Resolve(name string) (string, bool) {
+	for i := 0; i < 10; i++ { // Guard against alias cycles
+		url, ok := el.Emoji[name]
+		if !ok {
+			return name, false
+		}
+		if !strings.HasPrefix(url, "alias:") {
+			return name, true
+		}
+		name = strings.TrimPrefix(url, "alias:")
+	}
+	return name, false
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 type GroupAndChannelList struct {
 	Groups   *GroupList
 	Channels *ChannelList
 	IM       *InstantMessageList
 }
 
+// Returns the ID of the public channel with the given name or "" if it does not exist
+func (gcl *GroupAndChannelList) FindChannelByName(name string) string {
+	for _, cl := range gcl.Channels.Channels {
+		if cl.Name == name {
+			return cl.ID
+		}
+	}
+	return ""
+}
+
 func (gcl *GroupAndChannelList) IsChannel(id string) bool {
 	for _, cl := range gcl.Channels.Channels {
 		if cl.ID == id {
@@ -446,6 +557,7 @@ type GroupInfo struct {
 		} `json:"latest"`
 		UnreadCount int `json:"unread_count"`
 		UnreadCountDisplay int `json:"unread_count_display"`
+		MentionCountDisplay int `json:"mention_count_display"`
 		Members []string `json:"members"`
 		Topic struct {
 			Value string `json:"value"`
@@ -483,6 +595,7 @@ type ChannelInfo struct {
 		} `json:"latest"`
 		UnreadCount int `json:"unread_count"`
 		UnreadCountDisplay int `json:"unread_count_display"`
+		MentionCountDisplay int `json:"mention_count_display"`
 		Members []string `json:"members"`
 		Topic struct {
 			Value string `json:"value"`
@@ -499,6 +612,43 @@ type ChannelInfo struct {
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+// A channel as listed by conversations.list
+type Conversation struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsMember bool   `json:"is_member"`
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type ConversationInfo struct {
+	Ok bool `json:"ok"`
+	Channel struct {
+		ID string `json:"id"`
+		Name string `json:"name"`
+		NameNormalized string `json:"name_normalized"`
+		IsChannel bool `json:"is_channel"`
+		IsGroup bool `json:"is_group"`
+		IsIm bool `json:"is_im"`
+		IsMpim bool `json:"is_mpim"`
+		IsPrivate bool `json:"is_private"`
+		IsArchived bool `json:"is_archived"`
+		User string `json:"user"` // IM only
+		LastRead string `json:"last_read"`
+		UnreadCountDisplay int `json:"unread_count_display"`
+		MentionCountDisplay int `json:"mention_count_display"`
+		NumMembers int `json:"num_members"`
+		Topic struct {
+			Value string `json:"value"`
+		} `json:"topic"`
+		Purpose struct {
+			Value string `json:"value"`
+		} `json:"purpose"`
+	} `json:"channel"`
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 type PresenceChange struct {
 	User string `json:"user"`
 	Presence string `json:"presence"`
@@ -506,4 +656,15 @@ type PresenceChange struct {
 
 func (pc *PresenceChange) Type() MsgType {
 	return presence_change
-}
\ No newline at end of file
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Sent when the authenticated user changes their presence
+type ManualPresenceChange struct {
+	Presence string `json:"presence"`
+}
+
+func (mpc *ManualPresenceChange) Type() MsgType {
+	return manual_presence_change
+}
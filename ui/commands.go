@@ -0,0 +1,456 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"github.com/g-dx/rosslyn/slack"
+)
+
+// A slash command typed into the message editor
+type command struct {
+	name     string
+	args     string // Usage of the arguments, e.g. "@user [@user...]"
+	help     string
+	min, max int    // Number of arguments accepted, a negative max is unlimited
+	complete func(ctrl *controller, arg string) []string        // Candidates for the argument being typed, may be nil
+	run      func(ctrl *controller, args []string, text string) // text is everything following the name
+}
+
+func (c *command) usage() string {
+	return strings.TrimSpace(c.name + " " + c.args)
+}
+
+type commandRegistry struct {
+	cmds []*command // Sorted by name
+}
+
+func newCommandRegistry() *commandRegistry {
+	cr := &commandRegistry{}
+	cr.register(&command{name: "/help", help: "List the available commands", run: func(ctrl *controller, _ []string, _ string) {
+		ctrl.view = NewCommandHelpView(ctrl, ctrl.commands.cmds)
+		ctrl.Redraw()
+	}})
+	cr.register(&command{name: "/away", help: "Mark yourself away in every workspace", run: func(ctrl *controller, _ []string, _ string) {
+		ctrl.setPresence(ctrl.teams, "away")
+	}})
+	cr.register(&command{name: "/back", help: "Mark yourself active in every workspace", run: func(ctrl *controller, _ []string, _ string) {
+		ctrl.setPresence(ctrl.teams, "auto")
+	}})
+	cr.register(&command{name: "/me", args: "text", help: "Send an action message, e.g. /me waves", min: 1, max: -1,
+		run: func(ctrl *controller, _ []string, text string) {
+			ctrl.sendMeMessage(ctrl.chl, text)
+		}})
+	cr.register(&command{name: "/open", args: "@user [@user...]", help: "Open a direct or group conversation", min: 1, max: -1,
+		complete: completeUsers, run: func(ctrl *controller, args []string, _ string) {
+			ctrl.openConversationByName(args)
+		}})
+	cr.register(&command{name: "/join", args: "#channel", help: "Join a channel", min: 1, max: 1,
+		complete: completeChannels, run: func(ctrl *controller, args []string, _ string) {
+			ctrl.joinChannel(args[0])
+		}})
+	cr.register(&command{name: "/leave", help: "Leave the current channel", run: func(ctrl *controller, _ []string, _ string) {
+		ctrl.leaveChannel(ctrl.chl)
+	}})
+	cr.register(&command{name: "/create", args: "[--private] name", help: "Create a channel", min: 1, max: 2,
+		run: func(ctrl *controller, args []string, _ string) {
+			ctrl.createChannel(args)
+		}})
+	cr.register(&command{name: "/archive", help: "Archive the current channel", run: func(ctrl *controller, _ []string, _ string) {
+		ctrl.archiveChannel(ctrl.chl)
+	}})
+	cr.register(&command{name: "/invite", args: "@user [@user...]", help: "Invite users to the current channel", min: 1, max: -1,
+		complete: completeUsers, run: func(ctrl *controller, args []string, _ string) {
+			ctrl.inviteUsers(ctrl.chl, args)
+		}})
+	cr.register(&command{name: "/kick", args: "@user", help: "Remove a user from the current channel", min: 1, max: 1,
+		complete: completeUsers, run: func(ctrl *controller, args []string, _ string) {
+			ctrl.kickUser(ctrl.chl, args[0])
+		}})
+	cr.register(&command{name: "/topic", args: "[text]", help: "Set the topic of the current channel", max: -1,
+		run: func(ctrl *controller, _ []string, text string) {
+			ctrl.setTopic(ctrl.chl, text, false)
+		}})
+	cr.register(&command{name: "/purpose", args: "[text]", help: "Set the purpose of the current channel", max: -1,
+		run: func(ctrl *controller, _ []string, text string) {
+			ctrl.setTopic(ctrl.chl, text, true)
+		}})
+	return cr
+}
+
+func (cr *commandRegistry) register(c *command) {
+	i := sort.Search(len(cr.cmds), func(i int) bool { return cr.cmds[i].name >= c.name })
+	cr.cmds = append(cr.cmds, nil)
+	copy(cr.cmds[i+1:], cr.cmds[i:])
+	cr.cmds[i] = c
+}
+
+func (cr *commandRegistry) find(name string) *command {
+	for _, c := range cr.cmds {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Splits "/name arg arg" into the name, its arguments & the raw text following the name
+func parseCommand(text string) (name string, args []string, rest string) {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexAny(text, " \t"); i != -1 {
+		name, rest = text[:i], strings.TrimSpace(text[i:])
+	} else {
+		name = text
+	}
+	return name, strings.Fields(rest), rest
+}
+
+// Returns the possible completions of the whole text, which is either a partial command name or a command & a partial
+// argument
+func (cr *commandRegistry) complete(ctrl *controller, text string) []string {
+	var matches []string
+	i := strings.LastIndexAny(text, " \t")
+	if i == -1 {
+		for _, c := range cr.cmds {
+			if strings.HasPrefix(c.name, text) {
+				matches = append(matches, c.name+" ")
+			}
+		}
+		return matches
+	}
+
+	name, _, _ := parseCommand(text)
+	c := cr.find(name)
+	if c == nil || c.complete == nil {
+		return nil
+	}
+	for _, arg := range c.complete(ctrl, text[i+1:]) {
+		matches = append(matches, text[:i+1]+arg+" ")
+	}
+	return matches
+}
+
+func (ctrl *controller) RunCommand(text string) {
+	ctrl.status.msg = ""
+	name, args, rest := parseCommand(text)
+	c := ctrl.commands.find(name)
+	switch {
+	case c == nil && ctrl.slackCommands[name]:
+		ctrl.runSlackCommand(ctrl.chl, name, rest)
+	case c == nil:
+		ctrl.setStatus("Unknown command: %v (see /help)", name)
+	case len(args) < c.min || (c.max >= 0 && len(args) > c.max):
+		ctrl.setStatus("Usage: %v", c.usage())
+	default:
+		c.run(ctrl, args, rest)
+	}
+}
+
+// Completes the command being typed as far as is unambiguous, listing the candidates if there is more than one
+func (ctrl *controller) CompleteCommand(text string) string {
+	matches := ctrl.commands.complete(ctrl, text)
+	switch len(matches) {
+	case 0:
+		return text
+	case 1:
+		return matches[0]
+	}
+	ctrl.setInfo("%v", strings.Join(matches, " "))
+	if prefix := commonPrefix(matches); len(prefix) >= len(text) {
+		return prefix
+	}
+	return text
+}
+
+// Returns the longest prefix shared by every string, without splitting runes
+func commonPrefix(ss []string) string {
+	prefix := []rune(ss[0])
+	for _, s := range ss[1:] {
+		for !strings.HasPrefix(s, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
+
+// Slack's own commands, which are passed to it rather than implemented
+var builtinSlackCommands = []string{"/active", "/apps", "/collapse", "/dm", "/dnd", "/expand", "/feed", "/msg", "/mute",
+	"/remind", "/search", "/shortcuts", "/shrug", "/status", "/who"}
+
+// Returns Slack's own commands & the integration commands, which may be missing their leading '/'
+func newSlackCommands(integrations []string) map[string]bool {
+	cmds := make(map[string]bool)
+	for _, name := range builtinSlackCommands {
+		cmds[name] = true
+	}
+	for _, name := range integrations {
+		if name = strings.TrimSpace(name); name != "" {
+			cmds["/"+strings.TrimPrefix(name, "/")] = true
+		}
+	}
+	return cmds
+}
+
+// Commands we don't implement but Slack knows about are passed to it, e.g. integrations & custom commands
+func (ctrl *controller) runSlackCommand(cl *Channel, name, text string) {
+	ctrl.background(func() error {
+		if err := cl.team.apis.RunCommand(cl.id, name, text); err != nil {
+			return fmt.Errorf("Unable to run %v: %v", name, err)
+		}
+		return nil
+	}, func() {})
+}
+
+func completeUsers(ctrl *controller, arg string) []string {
+	var matches []string
+	prefix := strings.TrimPrefix(arg, "@")
+	for _, u := range ctrl.team.apis.GetUserList().Members {
+		if !u.Deleted && strings.HasPrefix(u.Name, prefix) {
+			matches = append(matches, "@"+u.Name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func completeChannels(ctrl *controller, arg string) []string {
+	ctrl.listChannels(ctrl.team)
+	var matches []string
+	prefix := strings.TrimPrefix(arg, "#")
+	for _, cl := range ctrl.team.publicChannels() {
+		if strings.HasPrefix(cl.Name, prefix) {
+			matches = append(matches, "#"+cl.Name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// Runs f in the background & then either displays its error or calls done
+func (ctrl *controller) background(f func() error, done func()) {
+	go func() {
+		err := f()
+		ctrl.userEvts <- func() {
+			if err != nil {
+				ctrl.setStatus("%v", err)
+				return
+			}
+			done()
+		}
+	}()
+}
+
+// Opens a conversation with one or more users ("@name") & switches to it
+func (ctrl *controller) openConversationByName(names []string) {
+	users, ok := ctrl.findUsers(names)
+	if ok {
+		ctrl.OpenConversation(users)
+	}
+}
+
+// Resolves "@name" to user IDs, displaying an error if any are unknown
+func (ctrl *controller) findUsers(names []string) ([]string, bool) {
+	users := make([]string, 0, len(names))
+	for _, name := range names {
+		id := ctrl.team.apis.GetUserList().FindByName(strings.TrimPrefix(name, "@"))
+		if id == "" {
+			ctrl.setStatus("Unknown user: %v", name)
+			return nil, false
+		}
+		users = append(users, id)
+	}
+	return users, true
+}
+
+// How long listed channels are used for completion before listing them again
+const listChannelsAge = 30 * time.Second
+
+// Refreshes the team's public channels in the background, unless they were listed recently
+func (ctrl *controller) listChannels(t *team) {
+	if time.Since(t.listed) < listChannelsAge {
+		return
+	}
+	t.listed = time.Now()
+	go func() {
+		chls, err := t.apis.ListPublicChannels()
+		ctrl.userEvts <- func() {
+			if err != nil {
+				ctrl.logger.Printf("Unable to list channels for %v: %v", t.name, err)
+				t.listed = time.Time{}
+				return
+			}
+			t.public = chls
+		}
+	}()
+}
+
+// Joins a public channel, listing them first so channels created since connecting are found
+func (ctrl *controller) joinChannel(name string) {
+	t := ctrl.team
+	name = strings.TrimPrefix(name, "#")
+
+	var chls []slack.Conversation
+	var info *slack.ConversationInfo
+	ctrl.background(func() (err error) {
+		chls, err = t.apis.ListPublicChannels()
+		return
+	}, func() {
+		t.public, t.listed = chls, time.Now()
+		id := findConversation(chls, name)
+		if id == "" {
+			ctrl.setStatus("Unknown channel: #%v", name)
+			return
+		}
+		if _, cl := t.chls.find(id); cl != nil {
+			ctrl.SwitchChannel(cl) // Already a member
+			return
+		}
+		ctrl.background(func() (err error) {
+			info, err = t.apis.JoinConversation(id)
+			return
+		}, func() {
+			ctrl.addChannel(t, info)
+		})
+	})
+}
+
+// Returns the ID of the named channel, or "" if it isn't listed
+func findConversation(chls []slack.Conversation, name string) string {
+	for _, cl := range chls {
+		if cl.Name == name {
+			return cl.ID
+		}
+	}
+	return ""
+}
+
+func (ctrl *controller) createChannel(args []string) {
+	private := len(args) > 0 && args[0] == "--private"
+	if private {
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		ctrl.setStatus("Usage: /create [--private] name")
+		return
+	}
+	t := ctrl.team
+	name := strings.TrimPrefix(args[0], "#")
+
+	var info *slack.ConversationInfo
+	ctrl.background(func() (err error) {
+		info, err = t.apis.CreateConversation(name, private)
+		return
+	}, func() {
+		ctrl.addChannel(t, info)
+	})
+}
+
+func (ctrl *controller) leaveChannel(cl *Channel) {
+	ctrl.background(func() error {
+		return cl.team.apis.LeaveConversation(cl.id)
+	}, func() {
+		ctrl.removeChannel(cl)
+	})
+}
+
+func (ctrl *controller) archiveChannel(cl *Channel) {
+	ctrl.background(func() error {
+		return cl.team.apis.ArchiveConversation(cl.id)
+	}, func() {
+		ctrl.removeChannel(cl)
+	})
+}
+
+func (ctrl *controller) inviteUsers(cl *Channel, names []string) {
+	users, ok := ctrl.findUsers(names)
+	if !ok {
+		return
+	}
+	ctrl.background(func() error {
+		return cl.team.apis.InviteToConversation(cl.id, users)
+	}, func() {
+		ctrl.setInfo("Invited %v to #%v", strings.Join(names, ", "), cl.name)
+	})
+}
+
+func (ctrl *controller) kickUser(cl *Channel, name string) {
+	users, ok := ctrl.findUsers([]string{name})
+	if !ok {
+		return
+	}
+	ctrl.background(func() error {
+		return cl.team.apis.KickFromConversation(cl.id, users[0])
+	}, func() {
+		ctrl.setInfo("Removed %v from #%v", name, cl.name)
+	})
+}
+
+// Sends an action message, which arrives back over RTM like any other message
+func (ctrl *controller) sendMeMessage(cl *Channel, text string) {
+	ctrl.background(func() error {
+		return cl.team.apis.SendMeMessage(cl.id, text)
+	}, func() {})
+}
+
+// Sets the topic (or purpose) of the channel
+func (ctrl *controller) setTopic(cl *Channel, text string, purpose bool) {
+	ctrl.background(func() error {
+		if purpose {
+			return cl.team.apis.SetConversationPurpose(cl.id, text)
+		}
+		return cl.team.apis.SetConversationTopic(cl.id, text)
+	}, func() {
+		if purpose {
+			cl.purpose = text
+		} else {
+			cl.topic = text
+		}
+		ctrl.Redraw()
+	})
+}
+
+// Adds a newly joined or created channel to the team & switches to it
+func (ctrl *controller) addChannel(t *team, info *slack.ConversationInfo) {
+	_, cl := t.chls.find(info.Channel.ID)
+	if cl == nil {
+		cl = t.newChannel(info, nil)
+		t.chls.add(cl)
+		ctrl.sidebar.invalidate()
+	}
+	ctrl.SwitchChannel(cl)
+}
+
+// Removes a channel we are no longer a member of & moves elsewhere if it was being viewed
+func (ctrl *controller) removeChannel(cl *Channel) {
+	t := cl.team
+	t.chls.remove(cl.id)
+	ctrl.sidebar.invalidate()
+	if t.chl == cl {
+		t.chl = nil
+		if t.chls.Size() > 0 {
+			t.chl = t.chls.chls[0]
+		}
+	}
+	ctrl.layout.closeChannel(cl)
+	ctrl.history.remove(cl)
+	if ctrl.unreadChl == cl {
+		ctrl.unreadChl = nil
+	}
+	if ctrl.chl != cl {
+		ctrl.Redraw()
+		return
+	}
+
+	// Replace the focused pane
+	ctrl.chl = nil
+	switch {
+	case t.chl != nil:
+		ctrl.showChannel(t.chl)
+	case len(ctrl.layout.panes) > 1:
+		ctrl.layout.close()
+	default:
+		ctrl.layout.clear()
+		ctrl.SelectChannel()
+	}
+}
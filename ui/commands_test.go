@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text       string
+		name, rest string
+		args       []string
+	}{
+		{"/away", "/away", "", []string{}},
+		{"/invite @bob  @alice ", "/invite", "@bob  @alice", []string{"@bob", "@alice"}},
+		{"/topic  Release day!", "/topic", "Release day!", []string{"Release", "day!"}},
+		{"/remind\tme in 5 minutes", "/remind", "me in 5 minutes", []string{"me", "in", "5", "minutes"}},
+	}
+
+	for _, data := range tests {
+		name, args, rest := parseCommand(data.text)
+		if name != data.name || rest != data.rest || !reflect.DeepEqual(args, data.args) {
+			t.Errorf("parseCommand(%q)\nGot : %q, %q, %q\nWant: %q, %q, %q", data.text, name, args, rest, data.name, data.args, data.rest)
+		}
+	}
+}
+
+func TestCommandRegistry(t *testing.T) {
+	cr := newCommandRegistry()
+	for i := 1; i < len(cr.cmds); i++ {
+		if cr.cmds[i-1].name >= cr.cmds[i].name {
+			t.Errorf("Commands not sorted: %v >= %v", cr.cmds[i-1].name, cr.cmds[i].name)
+		}
+	}
+	if cr.find("/help") == nil || cr.find("/remind") != nil {
+		t.Errorf("find() returned unexpected command")
+	}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"/a", []string{"/archive ", "/away "}},
+		{"/he", []string{"/help "}},
+		{"/x", nil},
+		{"/topic some", nil}, // No argument completion
+	}
+	for _, data := range tests {
+		got := cr.complete(nil, data.text)
+		if !reflect.DeepEqual(got, data.want) {
+			t.Errorf("complete(%q)\nGot : %q\nWant: %q", data.text, got, data.want)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"/join "}, "/join "},
+		{[]string{"/archive ", "/away "}, "/a"},
+		{[]string{"/join #café ", "/join #cafè "}, "/join #caf"},
+		{[]string{"/open @😀 ", "/open @😁 "}, "/open @"},
+	}
+	for _, test := range tests {
+		if got := commonPrefix(test.in); got != test.want {
+			t.Errorf("Got : %q\nWant: %q", got, test.want)
+		}
+	}
+}
+
+func TestSlackCommands(t *testing.T) {
+	cmds := newSlackCommands([]string{"/giphy", "poll", " ", ""})
+	for name, want := range map[string]bool{"/remind": true, "/giphy": true, "/poll": true, "/jion": false, "/": false} {
+		if cmds[name] != want {
+			t.Errorf("%v - Got : %v\nWant: %v", name, cmds[name], want)
+		}
+	}
+}
+
+func TestRunCommandArgs(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"/join", "Usage: /join #channel"},
+		{"/join #general #random", "Usage: /join #channel"},
+		{"/kick", "Usage: /kick @user"},
+		{"/kick @bob @alice", "Usage: /kick @user"},
+		{"/me", "Usage: /me text"},
+		{"/open", "Usage: /open @user [@user...]"},
+		{"/create", "Usage: /create [--private] name"},
+		{"/create a b c", "Usage: /create [--private] name"},
+		{"/create --private", "Usage: /create [--private] name"},
+		{"/create general random", "Usage: /create [--private] name"},
+		{"/jion #general", "Unknown command: /jion (see /help)"},
+	}
+	for _, test := range tests {
+		ctrl := &controller{commands: newCommandRegistry(), status: &Status{}, view: nullView{}}
+		ctrl.RunCommand(test.text)
+		if ctrl.status.msg != test.want {
+			t.Errorf("RunCommand(%q)\nGot : %q\nWant: %q", test.text, ctrl.status.msg, test.want)
+		}
+	}
+}
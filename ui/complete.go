@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"github.com/kyokomi/emoji/v2"
+	"github.com/nsf/termbox-go"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	maxCompletions     = 50
+	completionPopupMax = 8 // Visible rows
+)
+
+// A candidate for the word being typed
+type completion struct {
+	text  string // Replaces the word, e.g. "@bob"
+	label string // Displayed alongside, e.g. a real name
+	alt   string // Also matched against
+}
+
+// Returns true if the word should trigger completion. Commands can only begin the message.
+func isCompletionTrigger(word string, offset int) bool {
+	switch {
+	case word == "":
+		return false
+	case word[0] == '@', word[0] == '#':
+		return true
+	case word[0] == ':':
+		return len(word) > 1 && !strings.HasSuffix(word[1:], ":") // Ignore finished emoji
+	case word[0] == '/':
+		return offset == 0
+	}
+	return false
+}
+
+// Returns completions for a word beginning with a trigger character, best first
+func (ctrl *controller) Complete(word string) []completion {
+	var cs []completion
+	t := ctrl.team
+	switch word[0] {
+	case '@':
+		users := t.apis.GetUserList()
+		for _, u := range users.Members {
+			if !u.Deleted {
+				cs = append(cs, completion{text: "@" + u.Name, label: u.RealName, alt: u.RealName})
+			}
+		}
+		for _, v := range []string{"here", "channel", "everyone"} {
+			cs = append(cs, completion{text: "@" + v, label: "notify " + v})
+		}
+	case '#':
+		ctrl.listChannels(t)
+		for _, cl := range t.publicChannels() {
+			cs = append(cs, completion{text: "#" + cl.Name})
+		}
+		for _, cl := range t.chls.chls {
+			if cl.id[:1] == "G" && !cl.mpim {
+				cs = append(cs, completion{text: "#" + cl.name, label: "private"})
+			}
+		}
+	case ':':
+		cs = append(cs, emojiCompletions()...)
+		for name := range t.emoji.Emoji {
+			cs = append(cs, completion{text: ":" + name + ":", label: "custom"})
+		}
+	case '/':
+		for _, c := range ctrl.commands.cmds {
+			cs = append(cs, completion{text: c.name, label: c.help})
+		}
+		var names []string
+		for name := range ctrl.slackCommands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cs = append(cs, completion{text: name, label: "Slack command"})
+		}
+	}
+	return rankCompletions(word[1:], word[:1], cs)
+}
+
+// Orders completions by how well they fuzzily match the pattern, dropping those which don't
+func rankCompletions(pattern, trigger string, cs []completion) []completion {
+	type ranked struct {
+		completion
+		score int
+	}
+	var matches []ranked
+	for _, c := range cs {
+		name := strings.TrimSuffix(strings.TrimPrefix(c.text, trigger), ":")
+		if score, ok := fuzzyBest(pattern, name, c.alt); ok {
+			matches = append(matches, ranked{c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].text < matches[j].text
+	})
+
+	if len(matches) > maxCompletions {
+		matches = matches[:maxCompletions]
+	}
+	result := make([]completion, len(matches))
+	for i, m := range matches {
+		result[i] = m.completion
+	}
+	return result
+}
+
+var standardEmoji []completion
+
+// Standard emoji usable as shortcodes, built on first use
+func emojiCompletions() []completion {
+	if standardEmoji == nil {
+		for code, e := range emoji.CodeMap() {
+			if name := strings.Trim(code, ":"); emojiRegex.MatchString(name) {
+				standardEmoji = append(standardEmoji, completion{text: code, label: e})
+			}
+		}
+	}
+	return standardEmoji
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Popup listing completions for the word being typed in the editor
+type CompletionPopup struct {
+	matches []completion
+	word    string
+	offset  int // Position of the word within the editor text
+	pos     int
+	top     int // First visible match
+}
+
+func (cp *CompletionPopup) up() {
+	if cp.pos != 0 {
+		cp.pos--
+	} else {
+		cp.pos = len(cp.matches) - 1
+	}
+}
+
+func (cp *CompletionPopup) down() {
+	cp.pos = (cp.pos + 1) % len(cp.matches)
+}
+
+func (cp *CompletionPopup) current() completion {
+	return cp.matches[cp.pos]
+}
+
+// Draws the popup with its bottom left corner at x, y, keeping it within the width w
+func (cp *CompletionPopup) Draw(x, y, w int, term Terminal) {
+	width := 0
+	for _, m := range cp.matches {
+		if n := runewidth.StringWidth(m.text + "  " + m.label); n > width {
+			width = n
+		}
+	}
+	width += 2
+	if width > w-2 {
+		width = w - 2
+	}
+	if x+width+2 > w {
+		x = w - width - 2
+	}
+	rows := len(cp.matches)
+	if rows > completionPopupMax {
+		rows = completionPopupMax
+	}
+	cp.top = scrollWindow(cp.pos, cp.top, rows)
+
+	y0 := y - rows - 1
+	printBorder(x, y0, x+width+2, y+1, term)
+	for i := 0; i < rows; i++ {
+		m := cp.matches[cp.top+i]
+		fg, bg := termbox.ColorWhite, coldef
+		if cp.top+i == cp.pos {
+			fg, bg = termbox.ColorWhite, termbox.ColorYellow
+		}
+		line := runewidth.FillRight(" "+m.text, width)
+		printString(runewidth.Truncate(line, width, "…"), x+1, y0+1+i, fg, bg, term)
+		if lx := x + 1 + runewidth.StringWidth(" "+m.text+"  "); lx < x+width {
+			printString(runewidth.Truncate(m.label, x+width-lx, "…"), lx, y0+1+i, lineColour, bg, term)
+		}
+	}
+}
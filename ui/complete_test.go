@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsCompletionTrigger(t *testing.T) {
+	tests := []struct {
+		word   string
+		offset int
+		want   bool
+	}{
+		{"", 0, false},
+		{"hello", 0, false},
+		{"@", 6, true},
+		{"@bo", 0, true},
+		{"#gen", 4, true},
+		{":", 0, false},
+		{":piz", 0, true},
+		{":pizza:", 0, false},
+		{"/he", 0, true},
+		{"/he", 5, false},
+	}
+
+	for _, data := range tests {
+		got := isCompletionTrigger(data.word, data.offset)
+		if got != data.want {
+			t.Errorf("isCompletionTrigger(%q, %v)\nGot : '%v'\nWant: '%v'", data.word, data.offset, got, data.want)
+		}
+	}
+}
+
+func TestRankCompletions(t *testing.T) {
+	cs := []completion{
+		{text: "@bob", alt: "Robert Smith"},
+		{text: "@alice", alt: "Alice Jones"},
+		{text: "@bobby", alt: "Bobby Tables"},
+		{text: "@carol", alt: "Carol Bobson"},
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", []string{"@alice", "@bob", "@bobby", "@carol"}},
+		{"bob", []string{"@bob", "@bobby", "@carol"}},
+		{"smith", []string{"@bob"}},
+		{"xyz", []string{}},
+	}
+
+	for _, data := range tests {
+		got := []string{}
+		for _, c := range rankCompletions(data.pattern, "@", cs) {
+			got = append(got, c.text)
+		}
+		if !reflect.DeepEqual(got, data.want) {
+			t.Errorf("rankCompletions(%q)\nGot : %q\nWant: %q", data.pattern, got, data.want)
+		}
+	}
+}
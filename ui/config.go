@@ -0,0 +1,23 @@
+package ui
+
+import "time"
+
+const (
+	DefaultHistorySize = 50
+	DefaultTimeFormat  = "3:04 PM"
+)
+
+// User configurable settings for the controller
+type Config struct {
+	IdleTimeout    time.Duration // Mark ourselves away after this period of inactivity, 0 disables
+	Keywords       []string      // Highlight messages containing any of these words
+	Notify         NotifyConfig  // Which messages produce notifications
+	SidebarSort    SortOrder     // Initial order of channels within sidebar sections
+	SidebarWidth   int           // Initial width of the sidebar, 0 for the default
+	StarsFile      string        // Where locally starred channels are saved
+	Keymap         *Keymap       // Nil for the default key bindings
+	DefaultChannel string        // Name of the channel to start in, if the team has it
+	HistorySize    int           // Messages loaded at a time, 0 for the default
+	TimeFormat     string        // Layout of message times (see time.Format), "" for the default
+	SlackCommands  []string      // Integration commands to pass to Slack, e.g. "/giphy"
+}
@@ -6,147 +6,156 @@ import (
 	"log"
 	"fmt"
 	"time"
-	"github.com/0xAX/notificator"
 	"strconv"
 	"os"
 	"sort"
 	"html"
+	"io/ioutil"
+	"path/filepath"
 )
 
-// TODO: Put this behind an interface and add to controller
-var notify *notificator.Notificator
-var ui *log.Logger
-var debug *log.Logger
+var ui = log.New(ioutil.Discard, "", 0)
+var debug = log.New(ioutil.Discard, "", 0)
 
-func init() {
-	notify = notificator.New(notificator.Options{AppName: "Rosslyn", })
-
-	f, err := os.Create(os.ExpandEnv("${HOME}/.rosslyn/debug.log"))
+// Logs debugging & drawing information to debug.log & ui.log in the directory
+func OpenLogs(dir string) error {
+	f, err := os.Create(filepath.Join(dir, "debug.log"))
 	if err != nil {
-		panic(err)
+		return err
 	}
 	debug = log.New(f, "", log.Ldate | log.Ltime)
 
-	f, err = os.Create(os.ExpandEnv("${HOME}/.rosslyn/ui.log"))
+	f, err = os.Create(filepath.Join(dir, "ui.log"))
 	if err != nil {
-		panic(err)
+		return err
 	}
 	ui = log.New(f, "", 0)
+	return nil
 }
 
 type Controller interface {
 	SwitchChannel(cl *Channel)
+	FocusPane(cv *ChannelView)
 	SelectChannel()
+	SelectTeam()
+	SelectUsers()
+	SwitchTeam(t *team)
+	OpenConversation(users []string)
 	SendMessage(text string)
+	RunCommand(text string)
+	CompleteCommand(text string) string
+	Complete(word string) []completion
 	LoadMessages(cl *Channel)
+	LoadMembers(cl *Channel)
+	MarkRead(cl *Channel)
+	MarkUnread(cl *Channel, msg int)
 	Redraw()
 }
 
 type controller struct {
 	logger *log.Logger
+	cfg    Config
 
-	rtm *slack.RtmConnection
-	apis slack.Apis
-
-	termEvts chan termbox.Event
-	userEvts chan func()
+	mentions *mentionMatcher
+	notifier Notifier
+	rules    *notifyRules
+	commands *commandRegistry
+	sidebar  *Sidebar
 
-	chls       *ChannelList
-	chl        *Channel
+	slackCommands map[string]bool // Passed to Slack
 
-	chlsView *ChannelSelectionView
-	chlView  *ChannelView
-	view     View
-}
+	teams []*team
+	team  *team // Current team
 
-var userTypingTimer *UserTypingTimer
+	termEvts  chan termbox.Event
+	slackEvts chan teamEvent
+	userEvts  chan func()
 
-func NewController(logger *log.Logger, apis slack.Apis) *controller {
+	chl        *Channel
+	status     *Status
+	history    navHistory
+	keys       keyDecoder
+	keymap     *Keymap
+	chord      []keyEvent // Keys pressed so far of a longer binding
+	quitting   bool
+
+	idle     *time.Timer
+	autoAway []*team // Teams the idle timer marked us away in
+
+	marks     map[*Channel]string // Channels waiting to be marked as read
+	markTimer *time.Timer
+	unreadChl *Channel // Manually marked unread so don't mark as read until we leave it
+
+	teamsView *TeamSelectionView
+	chlView   *ChannelView
+	layout    *SplitView
+	view      View
+}
 
-	// Open connection
-	rtm := slack.NewRtmConnection(logger, apis.RtmConnect())
+func NewController(logger *log.Logger, apis []slack.Apis, cfg Config, notifier Notifier) *controller {
 
 	// Create controller
 	ctrl := &controller{
 		logger: logger,
-		rtm: rtm,
-		apis: apis,
+		cfg: cfg,
 		termEvts: make(chan termbox.Event, 5),
+		slackEvts: make(chan teamEvent, 5),
 		userEvts: make(chan func(), 5),
-		chls: &ChannelList{},
-	}
-
-	// Process groups
-	grpAndChl := apis.GetGroupAndChannelList()
-	for _, grp := range grpAndChl.Groups.Groups {
-		// TODO: Skip "multiple person IM" as it's not clear how to render this...
-		if !grp.IsMpim {
-			cl := &Channel { id: grp.ID, name: grp.NameNormalized }
-			go func() {
-				info := apis.GetGroupInfo(cl.id)
-				ctrl.userEvts <- func() {
-					cl.unread = info.Group.UnreadCountDisplay
-					if ctrl.isVisible(ctrl.chlsView) {
-						ctrl.Redraw()
-					}
-				}
-			}()
-			ctrl.chls.add(cl)
-		}
+		marks: make(map[*Channel]string),
+		mentions: newMentionMatcher(cfg.Keywords),
+		notifier: notifier,
+		rules: newNotifyRules(cfg.Notify),
+		commands: newCommandRegistry(),
+		slackCommands: newSlackCommands(cfg.SlackCommands),
+		keymap: cfg.Keymap,
 	}
-
-	// Process channels
-	for _, cl := range grpAndChl.Channels.Channels {
-		// Only add channels we are a member of
-		if cl.IsMember {
-			chl := &Channel { id: cl.ID, name: cl.NameNormalized }
-			go func() {
-				info := apis.GetChannelInfo(chl.id)
-				ctrl.userEvts <- func() {
-					chl.unread = info.Channel.UnreadCountDisplay
-					if ctrl.isVisible(ctrl.chlsView) {
-						ctrl.Redraw()
-					}
-				}
-			}()
-			ctrl.chls.add(chl)
-		}
+	if ctrl.keymap == nil {
+		ctrl.keymap = DefaultKeymap()
 	}
-
-	// Process IM
-	for _, im := range grpAndChl.IM.Ims {
-		// TODO: Remove our user name - not sure why it's here...
-		if apis.GetUserList().IsActive(im.User) {
-			cl := &Channel{id: im.ID, name: fmt.Sprintf("%-25v (%v)",
-				apis.GetUserList().GetRealName(im.User), apis.GetUserList().GetName(im.User)), user: im.User}
-			go func() {
-				info := apis.GetGroupInfo(cl.id)
-				ctrl.userEvts <- func() {
-					cl.unread = info.Group.UnreadCountDisplay
-					if ctrl.isVisible(ctrl.chlsView) {
-						ctrl.Redraw()
-					}
-				}
-			}()
-			ctrl.chls.add(cl)
-		}
+	if ctrl.cfg.HistorySize <= 0 {
+		ctrl.cfg.HistorySize = DefaultHistorySize
+	}
+	if ctrl.cfg.TimeFormat == "" {
+		ctrl.cfg.TimeFormat = DefaultTimeFormat
 	}
 
-	ctrl.chlsView = NewChannelListView(ctrl, ctrl.chls, apis.GetUserList())
-
-
-	// TODO: Move me elsewhere
-	userTypingTimer = &UserTypingTimer{typingTimeout, make(map[string]*time.Timer), ctrl.userEvts }
+	// Connect to each team
+	for _, api := range apis {
+		ctrl.teams = append(ctrl.teams, ctrl.newTeam(api))
+	}
+	ctrl.status = &Status{teams: ctrl.teams}
+	if cfg.DefaultChannel != "" && !anyTeamHas(ctrl.teams, cfg.DefaultChannel) {
+		// Don't quietly start somewhere else
+		ctrl.status.msg = fmt.Sprintf("Default channel not found in any team: %v", cfg.DefaultChannel)
+	}
+	stars, err := LoadStars(cfg.StarsFile)
+	if err != nil {
+		logger.Printf("Unable to load stars: %v", err)
+	}
+	ctrl.sidebar = NewSidebar(ctrl, ctrl.status, stars, cfg.SidebarSort)
+	ctrl.layout = NewSplitView(ctrl, ctrl.status, ctrl.sidebar, cfg.SidebarWidth, ctrl.cfg.TimeFormat)
+	ctrl.teamsView = NewTeamSelectionView(ctrl, ctrl.teams)
 
+	// Start in the first team
+	ctrl.SwitchTeam(ctrl.teams[0])
 
-	// Find, load and set #edinburgh-ticketing as default
-	_, edinburgh := ctrl.chls.find("G0L95H9TL") // #edinburgh-ticketing
-	ctrl.SwitchChannel(edinburgh)
+	// Mark us away even if no key is ever pressed
+	ctrl.resetIdle()
 
 	go ctrl.eventLoop()
 	return ctrl
 }
 
+// Returns true if any of the teams has a channel (not a DM) with the name
+func anyTeamHas(teams []*team, name string) bool {
+	for _, t := range teams {
+		if t.chls.findByName(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (ctrl *controller) eventLoop() {
 	for {
 		ctrl.termEvts <- termbox.PollEvent()
@@ -158,8 +167,8 @@ func (ctrl *controller) Run() {
 	ctrl.Redraw()
 	for {
 		select {
-		case evt := <-ctrl.rtm.ReadEvent():
-			ctrl.onSlackEvent(evt)
+		case te := <-ctrl.slackEvts:
+			ctrl.onSlackEvent(te.t, te.evt)
 		case ev := <- ctrl.termEvts:
 			if !ctrl.onTerminalEvent(ev) {
 				return // Shutdown
@@ -173,30 +182,152 @@ func (ctrl *controller) Run() {
 func (ctrl *controller) onTerminalEvent(ev termbox.Event) bool {
 	switch ev.Type {
 	case termbox.EventKey:
-		switch ev.Key {
-		case termbox.KeyCtrlQ:
-			ctrl.rtm.Close()
-			return false
-		case termbox.KeyCtrlW:
-			// Debug
-			ctrl.userEvts <- func() {
-				ctrl.onSlackEvent(&slack.PresenceChange{ "U0N4UV70Q", "away"})
-			}
-		default:
-			ctrl.view.OnKey(ev.Key, ev.Ch) // Current view
+		ctrl.onActivity()
+		for _, k := range ctrl.keys.feed(ev) {
+			ctrl.onKey(k)
+		}
+		if ctrl.keys.waiting() {
+			seq := ctrl.keys.seq
+			time.AfterFunc(escapeDelay, func() { ctrl.userEvts <- func() { ctrl.flushKeys(seq) } })
 		}
 	case termbox.EventResize:
 		ctrl.Redraw()
 	case termbox.EventError:
 		panic(ev.Err)
 	}
-	return true
+	return !ctrl.quitting
+}
+
+// Runs the action bound to the key, or the keys of a longer binding pressed so far. Otherwise passes the key to the
+// current view.
+func (ctrl *controller) onKey(k keyEvent) {
+	ctrl.chord = append(ctrl.chord, k.normalise())
+	a, prefix := ctrl.keymap.lookup(ctrl.keyContexts(), ctrl.chord)
+	switch {
+	case a != nil:
+		if len(ctrl.chord) > 1 {
+			ctrl.status.msg = ""
+		}
+		ctrl.chord = nil
+		a.run(ctrl)
+	case prefix:
+		ctrl.status.msg, ctrl.status.info = formatKeys(ctrl.chord) + " …", true
+		ctrl.Redraw()
+	case len(ctrl.chord) > 1:
+		ctrl.status.msg = ""
+		if k.key != termbox.KeyEsc {
+			ctrl.status.msg, ctrl.status.info = fmt.Sprintf("%v is not bound", formatKeys(ctrl.chord)), false
+		}
+		ctrl.chord = nil
+		ctrl.Redraw()
+	default:
+		ctrl.chord = nil
+		ctrl.view.OnKey(k.key, k.ch) // Current view
+	}
+}
+
+// Returns the contexts of the key bindings available in the current view, most specific first
+func (ctrl *controller) keyContexts() []string {
+	switch v := ctrl.view.(type) {
+	case *SplitView:
+		switch {
+		case v.sidebar.focused:
+			return []string{contextSidebar, contextLayout, contextGlobal}
+		case len(v.panes) == 0:
+			return []string{contextGlobal} // Layout actions need a pane
+		case v.main().members != nil && v.main().members.focused:
+			return []string{contextMembers, contextLayout, contextGlobal}
+		case v.main().completions != nil:
+			return []string{contextCompletion, contextEditor, contextChannel, contextLayout, contextGlobal}
+		}
+		return []string{contextEditor, contextChannel, contextLayout, contextGlobal}
+	case *ChannelSelectionView:
+		return []string{contextChannels, contextGlobal}
+	case *TeamSelectionView:
+		return []string{contextTeams, contextGlobal}
+	case *UserSelectionView:
+		return []string{contextUsers, contextGlobal}
+	case helpView:
+		return []string{contextHelp, contextGlobal}
+	}
+	return []string{contextGlobal}
+}
+
+func (ctrl *controller) quit() {
+	for _, t := range ctrl.teams {
+		t.rtm.Close()
+	}
+	ctrl.quitting = true
+}
+
+// Passes on key presses held back after Esc if nothing has been pressed since
+func (ctrl *controller) flushKeys(seq int) {
+	if ctrl.keys.seq != seq {
+		return
+	}
+	for _, k := range ctrl.keys.flush() {
+		ctrl.onKey(k)
+	}
 }
 
 func (ctrl *controller) Redraw() {
 	ctrl.view.Draw(&terminal{})
 }
 
+const markDelay = 2 * time.Second
+
+// Marks a channel on screen as read if its last message is visible, called whenever that may have changed. Marks are
+// sent after a short delay to avoid flooding Slack while scrolling or chatting.
+func (ctrl *controller) MarkRead(cl *Channel) {
+	if !ctrl.isViewing(cl) || cl == ctrl.unreadChl || !cl.hasUnread() {
+		return
+	}
+	cl.lastRead = cl.msgs[len(cl.msgs)-1].Ts
+	cl.unread = 0
+	cl.mentions = 0
+	ctrl.sidebar.invalidate()
+	ctrl.marks[cl] = cl.lastRead
+	if ctrl.markTimer == nil {
+		ctrl.markTimer = time.AfterFunc(markDelay, func() { ctrl.userEvts <- ctrl.sendMarks })
+	}
+}
+
+// Moves the read cursor to just before the given message
+func (ctrl *controller) MarkUnread(cl *Channel, msg int) {
+	if msg < 0 || msg >= len(cl.msgs) {
+		return
+	}
+	ts := tsBefore(cl.msgs[msg].Ts)
+	if msg > 0 {
+		ts = cl.msgs[msg-1].Ts
+	}
+	cl.lastRead = ts
+	cl.unread, cl.mentions = cl.countAfter(ts)
+	ctrl.unreadChl = cl
+	delete(ctrl.marks, cl)
+	ctrl.sidebar.invalidate()
+
+	ctrl.background(func() error {
+		return cl.team.apis.MarkChannel(cl.id, ts)
+	}, func() {
+		ctrl.setInfo("Marked unread from %v", cl.msgs[msg].T.Format("Jan 2 3:04 PM"))
+	})
+	ctrl.Redraw()
+}
+
+func (ctrl *controller) sendMarks() {
+	for cl, ts := range ctrl.marks {
+		cl, ts := cl, ts
+		go func() {
+			if err := cl.team.apis.MarkChannel(cl.id, ts); err != nil {
+				ctrl.logger.Printf("Unable to mark '%v' as read: %v", cl.id, err)
+			}
+		}()
+	}
+	ctrl.marks = make(map[*Channel]string)
+	ctrl.markTimer = nil
+}
+
 
 func (ctrl *controller) LoadMessages(cl *Channel) {
 
@@ -206,8 +337,8 @@ func (ctrl *controller) LoadMessages(cl *Channel) {
 		start = fromTsToTime(cl.msgs[0].Ts)
 	}
 
-	users := ctrl.apis.GetUserList()
-	history := ctrl.apis.GetChannelHistory(cl.id, start)
+	users := cl.team.apis.GetUserList()
+	history := cl.team.apis.GetChannelHistory(cl.id, start, ctrl.cfg.HistorySize)
 
 	msgs := make([]*Message, 0, len(history.Messages))
 
@@ -217,21 +348,24 @@ func (ctrl *controller) LoadMessages(cl *Channel) {
 
 		// TODO: what about other events?
 		if (msg.Type == "message") {
-			fe := Formatter{lookup: &slackLookup {users }}
+			fe := Formatter{lookup: cl.team.lookup()}
 			content, styles := fe.Format(html.UnescapeString(msg.Text))
 			msgs = append(msgs, &Message{
-				Text:     string(content),
-				Ts:       msg.Ts,
-				T:        tsToTime(msg.Ts),
-				User:     users.GetName(msg.User),
-				IsEdited: msg.Edited.Ts != "", // TODO: Is there a better way to handle this?
-				Formats:  styles,
+				Text:      string(content),
+				Ts:        msg.Ts,
+				T:         tsToTime(msg.Ts),
+				User:      users.GetName(msg.User),
+				IsEdited:  msg.Edited.Ts != "", // TODO: Is there a better way to handle this?
+				IsMention: msg.User != cl.team.self && ctrl.mentions.matches(cl.team.self, msg.Text, string(content)),
+				IsAction:  msg.Subtype.IsMe(),
+				Formats:   styles,
 			})
 		}
 	}
 
 	// Add to start of message list and correct pos
 	cl.msgs = append(msgs, cl.msgs...)
+	ctrl.sidebar.invalidate()
 	inc := len(history.Messages)-1
 	if inc < 0 {
 		inc = 0
@@ -239,79 +373,277 @@ func (ctrl *controller) LoadMessages(cl *Channel) {
 	cl.pos += inc
 }
 
+func (ctrl *controller) LoadMembers(cl *Channel) {
+	var members []string
+	ctrl.background(func() (err error) {
+		members, err = cl.team.apis.GetConversationMembers(cl.id)
+		return
+	}, func() {
+		cl.memberIDs = members
+		cl.members = len(members)
+		ctrl.layout.matchMembers()
+		ctrl.Redraw()
+	})
+}
+
 func (ctrl *controller) SelectChannel() {
-	ctrl.view = ctrl.chlsView
+	ctrl.team.chlsView.reset()
+	ctrl.view = ctrl.team.chlsView
 	ctrl.Redraw()
 }
 
-func (ctrl *controller) SendMessage(msg string) {
-	ctrl.rtm.SendEvent(slack.NewSimpleMessage(ctrl.chl.id, msg))
+func (ctrl *controller) SelectTeam() {
+	ctrl.view = ctrl.teamsView
+	ctrl.Redraw()
+}
+
+func (ctrl *controller) SelectUsers() {
+	ctrl.view = NewUserSelectionView(ctrl, ctrl.team.apis.GetUserList(), ctrl.team.self)
+	ctrl.Redraw()
+}
+
+func (ctrl *controller) SwitchTeam(t *team) {
+	ctrl.team = t
+	ctrl.status.team = t
+	if t.chl == nil {
+		ctrl.SelectChannel() // Nothing to display yet
+		return
+	}
+	ctrl.SwitchChannel(t.chl)
+}
 
+func (ctrl *controller) SendMessage(msg string) {
+	ctrl.status.msg = ""
+	lookup := ctrl.chl.team.lookup()
+	text := encodeMessage(msg, lookup)
+	ctrl.chl.team.rtm.SendEvent(slack.NewSimpleMessage(ctrl.chl.id, text))
+
+	// Display as it will be received
+	fe := Formatter{ lookup: lookup }
+	content, styles := fe.Format(text)
 	now := time.Now()
-	ctrl.chl.AddSent(&Message{ Text: msg, Ts: fmt.Sprintf("%v.00000", strconv.FormatInt(now.Unix(), 10)), T: now, User: "garyduprex"})
+	ctrl.chl.AddSent(&Message{ Text: string(content), Formats: styles, Ts: fmt.Sprintf("%v.00000", strconv.FormatInt(now.Unix(), 10)), T: now, User: ctrl.chl.team.selfName})
+	ctrl.sidebar.invalidate()
+	ctrl.MarkRead(ctrl.chl)
 	ctrl.Redraw()
 }
 
-func (ctrl *controller) onSlackEvent(evt slack.Event) {
+// Opens (or creates) a conversation with one or more users & switches to it
+func (ctrl *controller) OpenConversation(users []string) {
+	t := ctrl.team
+	go func() {
+		id, err := t.apis.OpenConversation(users)
+		ctrl.userEvts <- func() {
+			if err != nil {
+				ctrl.setStatus("Unable to open conversation: %v", err)
+				return
+			}
+			ctrl.loadConversation(t, id, ctrl.SwitchChannel)
+		}
+	}()
+}
+
+// Sets our presence in each team to "auto" or "away" in the background
+func (ctrl *controller) setPresence(teams []*team, presence string) {
+	for _, t := range teams {
+		t := t
+		go func() {
+			err := t.apis.SetPresence(presence)
+			ctrl.userEvts <- func() {
+				if err != nil {
+					ctrl.setStatus("Unable to set presence for %v: %v", t.name, err)
+					return
+				}
+				// Slack will send a "manual_presence_change" but there is no need to wait
+				t.presence = "active"
+				if presence == "away" {
+					t.presence = "away"
+				}
+				ctrl.Redraw()
+			}
+		}()
+	}
+}
+
+// Restarts the idle timer & marks us back if it had previously marked us away
+func (ctrl *controller) onActivity() {
+	if ctrl.cfg.IdleTimeout <= 0 {
+		return // Disabled
+	}
+	if len(ctrl.autoAway) > 0 {
+		ctrl.setPresence(ctrl.autoAway, "auto")
+		ctrl.autoAway = nil
+	}
+	ctrl.resetIdle()
+}
+
+// Starts or restarts the idle timer, if enabled
+func (ctrl *controller) resetIdle() {
+	if ctrl.cfg.IdleTimeout <= 0 {
+		return
+	}
+	if ctrl.idle == nil {
+		ctrl.idle = time.AfterFunc(ctrl.cfg.IdleTimeout, func() { ctrl.userEvts <- ctrl.onIdle })
+	} else {
+		ctrl.idle.Reset(ctrl.cfg.IdleTimeout)
+	}
+}
+
+func (ctrl *controller) onIdle() {
+	// Don't override a manually set "away"
+	for _, t := range ctrl.teams {
+		if t.presence != "away" {
+			ctrl.autoAway = append(ctrl.autoAway, t)
+		}
+	}
+	ctrl.setPresence(ctrl.autoAway, "away")
+}
+
+// Displays an error in the status line until it is cleared by the next message
+func (ctrl *controller) setStatus(format string, args ...interface{}) {
+	ctrl.status.msg, ctrl.status.info = fmt.Sprintf(format, args...), false
+	ctrl.Redraw()
+}
+
+// Displays information, e.g. that a command succeeded, in the status line until it is cleared by the next message
+func (ctrl *controller) setInfo(format string, args ...interface{}) {
+	ctrl.status.msg, ctrl.status.info = fmt.Sprintf(format, args...), true
+	ctrl.Redraw()
+}
+
+func (ctrl *controller) onSlackEvent(t *team, evt slack.Event) {
 	switch msg := evt.(type) {
 	case *slack.SimpleMessage:
-		ctrl.onMessage(msg)
+		ctrl.onMessage(t, msg)
 	case *slack.Response:
 		ctrl.onResponse(msg)
 	case *slack.UserTyping:
-		ctrl.onUserTyping(msg)
+		ctrl.onUserTyping(t, msg)
 	case *slack.DesktopNotification:
-		ctrl.onDesktopNotification(msg)
+		ctrl.onDesktopNotification(t, msg)
 	case *slack.MessageChanged:
-		ctrl.onChangedMessage(msg)
+		ctrl.onChangedMessage(t, msg)
 	case *slack.MessageDeleted:
 		ctrl.onDeletedMessage(msg)
 	case *slack.MessageThreadReply:
 		ctrl.onThreadReplyMessage(msg)
+	case *slack.TopicChanged:
+		ctrl.onTopicChangedMessage(t, msg)
 	case *slack.PresenceChange:
-		ctrl.onPresenceChangeMessage(msg)
+		ctrl.onPresenceChangeMessage(t, msg)
+	case *slack.ManualPresenceChange:
+		ctrl.onManualPresenceChangeMessage(t, msg)
 	default:
-		ctrl.logger.Println("Unhandled Event: %v", msg)
+		ctrl.logger.Printf("Unhandled Event: %v", msg)
 	}
 }
-func (ctrl *controller) onPresenceChangeMessage(change *slack.PresenceChange) {
-	ctrl.apis.GetUserList().SetPresence(change.User, change.Presence)
-	if ctrl.isVisible(ctrl.chlsView) {
+
+func (ctrl *controller) onManualPresenceChangeMessage(t *team, change *slack.ManualPresenceChange) {
+	t.presence = change.Presence
+	ctrl.Redraw()
+}
+func (ctrl *controller) onPresenceChangeMessage(t *team, change *slack.PresenceChange) {
+	t.apis.GetUserList().SetPresence(change.User, change.Presence)
+	ctrl.layout.matchMembers()
+	if ctrl.isVisible(t.chlsView) || (ctrl.isVisible(ctrl.layout) && ctrl.team == t) {
 		ctrl.Redraw()
 	}
 }
 
+func (ctrl *controller) onTopicChangedMessage(t *team, change *slack.TopicChanged) {
+	if _, cl := t.chls.find(change.Channel); cl != nil {
+		changeTopic(cl, change)
+	}
+
+	// Display as a regular message too
+	ctrl.onMessage(t, &slack.SimpleMessage{Channel: change.Channel, User: change.User, Text: change.Text, Ts: change.Ts})
+}
+
+// Sets whichever of the topic or purpose changed, either may be cleared
+func changeTopic(cl *Channel, change *slack.TopicChanged) {
+	if change.IsPurpose() {
+		cl.purpose = change.Purpose
+	} else {
+		cl.topic = change.Topic
+	}
+}
+
 func (ctrl *controller) onThreadReplyMessage(reply *slack.MessageThreadReply) {
 	// Skip until message threads are implemented...
 }
 
-func (ctrl *controller) onDesktopNotification(alrt *slack.DesktopNotification) {
-	notify.Push(fmt.Sprintf("New message from %v", alrt.Subtitle), alrt.Content, "", notificator.UR_NORMAL)
+// Slack flags messages it thinks we should be notified about. These are treated like mentions but the rules still
+// apply, including skipping messages we have already notified for.
+func (ctrl *controller) onDesktopNotification(t *team, alrt *slack.DesktopNotification) {
+	_, chl := t.chls.find(alrt.Channel)
+	if chl == nil || ctrl.isViewing(chl) || !ctrl.rules.allow(chl, alrt.Msg, true, alrt.Content, time.Now()) {
+		return
+	}
+	ctrl.notify(t, fmt.Sprintf("New message from %v", alrt.Subtitle), alrt.Content)
 }
 
-func (ctrl *controller) onMessage(msg *slack.SimpleMessage) {
+func (ctrl *controller) notify(t *team, title, body string) {
+	if len(ctrl.teams) > 1 {
+		title = fmt.Sprintf("[%v] %v", t.name, title)
+	}
+	if err := ctrl.notifier.Notify(title, body); err != nil {
+		ctrl.logger.Printf("Unable to notify: %v", err)
+	}
+}
 
-	_, chl := ctrl.chls.find(msg.Channel)
+// Returns true if the channel is on screen & scrolled to the bottom
+func (ctrl *controller) isViewing(cl *Channel) bool {
+	return ctrl.isVisible(ctrl.layout) && ctrl.layout.shows(cl) && cl.pos == len(cl.msgs)-1
+}
+
+// Returns true if a new message in the channel should count as unread: it's off screen, or was marked unread by hand &
+// so stays unread until we leave it
+func (ctrl *controller) countsUnread(cl *Channel) bool {
+	return !ctrl.isViewing(cl) || cl == ctrl.unreadChl
+}
+
+func (ctrl *controller) onMessage(t *team, msg *slack.SimpleMessage) {
+
+	_, chl := t.chls.find(msg.Channel)
 	if chl == nil {
-		ctrl.logger.Printf("Channel '%v' not found for new message - skipping...") // MPIM messages...
+		// Likely a new conversation, load it & try again
+		ctrl.logger.Printf("Channel '%v' not found for new message - loading...", msg.Channel)
+		ctrl.loadConversation(t, msg.Channel, func(*Channel) { ctrl.onMessage(t, msg) })
 		return
 	}
 
 	// Don't bother displaying 'reply_to' - it's not exactly clear what they are for...
 	if !msg.IsReplyTo() {
-		userList := ctrl.apis.GetUserList()
+		userList := t.apis.GetUserList()
 
 		// Separate formatting from content
-		fe := Formatter{ lookup: &slackLookup{userList }}
+		fe := Formatter{ lookup: t.lookup() }
 		content, styles := fe.Format(msg.Text)
 
-		chl.AddReceived(&Message{
-			User:    userList.GetName(msg.User),
-			Ts:      msg.Ts,
-			T:       tsToTime(msg.Ts),
-			Text:    string(content),
-			Formats: styles,
-		})
+		m := &Message{
+			User:      userList.GetName(msg.User),
+			Ts:        msg.Ts,
+			T:         tsToTime(msg.Ts),
+			Text:      string(content),
+			IsMention: msg.User != t.self && ctrl.mentions.matches(t.self, msg.Text, string(content)),
+			IsAction:  msg.Subtype.IsMe(),
+			Formats:   styles,
+		}
+		chl.AddReceived(m)
+		ctrl.sidebar.invalidate()
+
+		if msg.User != t.self && ctrl.countsUnread(chl) {
+			chl.unread++
+			if m.IsMention {
+				chl.mentions++
+			}
+			ctrl.onUnreadChanged(t)
+
+			if ctrl.rules.allow(chl, m.Ts, m.IsMention, m.Text, time.Now()) {
+				ctrl.notify(t, notificationTitle(chl, m.User), m.Text)
+			}
+		}
+		ctrl.MarkRead(chl)
 	}
 
 	if msg.IsEdit() {
@@ -320,77 +652,136 @@ func (ctrl *controller) onMessage(msg *slack.SimpleMessage) {
 	}
 
 	// Remove them from "typing" monitor
-	userTypingTimer.Remove(ctrl.apis.GetUserList().GetRealName(msg.User))
+	if chl.typing != nil {
+		chl.typing.Remove(t.apis.GetUserList().GetRealName(msg.User))
+	}
 	ctrl.Redraw()
 }
 
+func notificationTitle(cl *Channel, user string) string {
+	switch {
+	case cl.user != "":
+		return fmt.Sprintf("New message from %v", user)
+	case cl.mpim:
+		return fmt.Sprintf("New message from %v in %v", user, cl.name)
+	default:
+		return fmt.Sprintf("New message from %v in #%v", user, cl.name)
+	}
+}
+
+// Redraws any view displaying unread counts for the team
+func (ctrl *controller) onUnreadChanged(t *team) {
+	ctrl.sidebar.invalidate()
+	if ctrl.isVisible(t.chlsView) || ctrl.isVisible(ctrl.teamsView) || ctrl.isVisible(ctrl.layout) {
+		ctrl.Redraw()
+	}
+}
+
+// Shows the channel in the focused pane, remembering the previous one for navigating back
 func (ctrl *controller) SwitchChannel(cl *Channel) {
+	if cl == nil && len(ctrl.layout.panes) == 0 {
+		ctrl.SelectChannel() // No channel shown yet to go back to
+		return
+	}
+	if cl != nil {
+		ctrl.history.visit(ctrl.chl, cl)
+	}
+	ctrl.view = ctrl.layout
+	ctrl.showChannel(cl)
+}
+
+// Shows the channel in the focused pane, its scroll position is kept by the channel & the draft is restored
+func (ctrl *controller) showChannel(cl *Channel) {
 	if cl != nil {
 		if len(cl.msgs) == 0 {
 			ctrl.LoadMessages(cl)
 		}
-		// Set channel
-		ctrl.chl = cl
-		ctrl.chlView = NewChannelView(ctrl, ctrl.chl)
-		ctrl.view = ctrl.chlView
-
-		// Clear existing "typing users"
-		userTypingTimer.Clear()
-	} else {
-		ctrl.view = ctrl.chlView
+		cv := ctrl.layout.show(cl)
+		ctrl.FocusPane(cv)
+		ctrl.view = ctrl.layout
+	}
+	for _, cv := range ctrl.layout.panes {
+		ctrl.MarkRead(cv.cl)
 	}
 	ctrl.Redraw()
 }
 
+// Makes the pane's channel current, so commands & messages go to it
+func (ctrl *controller) FocusPane(cv *ChannelView) {
+	cl := cv.cl
+	if cl != ctrl.unreadChl {
+		ctrl.unreadChl = nil
+	}
+	ctrl.chl = cl
+	cl.viewed = time.Now()
+	ctrl.sidebar.invalidate()
+	ctrl.team = cl.team
+	ctrl.status.team = cl.team
+	cl.team.chl = cl
+	ctrl.chlView = cv
+}
+
 func (ctrl *controller) onResponse(resp *slack.Response) {
 
 	// Find message with `reply_to` id and mark as ok or failed
-	ctrl.logger.Println("Received Response: %v", resp)
+	ctrl.logger.Printf("Received Response: %v", resp)
 }
 
-func (ctrl *controller) findChannel(id string) *Channel {
+func (ctrl *controller) findChannel(t *team, id string) *Channel {
 	// Fast-path
-	if ctrl.chl.id == id {
+	if ctrl.chl != nil && ctrl.chl.team == t && ctrl.chl.id == id {
 		return ctrl.chl
 	}
-	_, chl := ctrl.chls.find(id)
+	_, chl := t.chls.find(id)
 	return chl
 }
 
-func (ctrl *controller) onUserTyping(typing *slack.UserTyping) {
-	if ctrl.chl.id == typing.Channel {
-		// TODO: Switch back to User IDs and let the front end render the ID how it wants
-		userTypingTimer.Add(ctrl.apis.GetUserList().GetRealName(typing.User), func() {
-			userTypingTimer.Remove(ctrl.apis.GetUserList().GetRealName(typing.User))
-			if ctrl.isVisible(ctrl.chlView) {
-				ctrl.Redraw()
-			}
-		})
-		if ctrl.isVisible(ctrl.chlView)  {
+func (ctrl *controller) onUserTyping(t *team, typing *slack.UserTyping) {
+	chl := ctrl.findChannel(t, typing.Channel)
+	if chl == nil {
+		return
+	}
+	if chl.typing == nil {
+		chl.typing = &UserTypingTimer{typingTimeout, make(map[string]*time.Timer), ctrl.userEvts }
+	}
+
+	// TODO: Switch back to User IDs and let the front end render the ID how it wants
+	name := t.apis.GetUserList().GetRealName(typing.User)
+	chl.typing.Add(name, func() {
+		chl.typing.Remove(name)
+		if ctrl.isVisible(ctrl.layout) && ctrl.layout.shows(chl) {
 			ctrl.Redraw()
 		}
+	})
+	if ctrl.isVisible(ctrl.layout) && ctrl.layout.shows(chl) {
+		ctrl.Redraw()
 	}
 }
 func (ctrl *controller) isVisible(view View) bool {
 	return ctrl.view == view
 }
 
-func (ctrl *controller) onChangedMessage(edit *slack.MessageChanged) {
-	chl := ctrl.findChannel(edit.Channel)
+func (ctrl *controller) onChangedMessage(t *team, edit *slack.MessageChanged) {
+	chl := ctrl.findChannel(t, edit.Channel)
+	if chl == nil {
+		return
+	}
 	msg := chl.findByTs(edit.PreviousMessage.Ts)
 	if msg != nil {
 		// Update TS
 		msg.Ts = edit.Message.Ts
 
 		// Parse style & update content
-		fe := Formatter{ lookup: &slackLookup{ctrl.apis.GetUserList() }}
+		fe := Formatter{ lookup: t.lookup() }
 		content, styles := fe.Format(edit.Message.Text)
 		msg.Text = string(content)
 		msg.Formats = styles
 		msg.IsEdited = true
+		msg.IsAction = edit.Message.Subtype.IsMe()
+		msg.IsMention = edit.Message.User != t.self && ctrl.mentions.matches(t.self, edit.Message.Text, msg.Text)
 
 		// Only redraw if are on screen
-		if ctrl.chl.id == chl.id {
+		if ctrl.layout.shows(chl) {
 			ctrl.Redraw()
 		}
 	}
@@ -449,7 +840,10 @@ func (utt *UserTypingTimer) UsersTyping() []string {
 // TODO: Could update *slack.UserList to implement this interface directly....
 
 type slackLookup struct {
-	user *slack.UserList
+	user  *slack.UserList
+	emoji *slack.EmojiList
+	chls  *ChannelList                // Conversations we are a member of
+	all   *slack.GroupAndChannelList // All public channels
 }
 
 func (sl *slackLookup) GetUser(id string) string {
@@ -457,6 +851,30 @@ func (sl *slackLookup) GetUser(id string) string {
 }
 
 func (sl *slackLookup) GetChannel(channel string) string {
-	// TODO!
+	if _, cl := sl.chls.find(channel); cl != nil {
+		return cl.name
+	}
+	for _, cl := range sl.all.Channels.Channels {
+		if cl.ID == channel {
+			return cl.Name
+		}
+	}
 	return channel
 }
+
+func (sl *slackLookup) FindUser(name string) string {
+	return sl.user.FindByName(name)
+}
+
+func (sl *slackLookup) FindChannel(name string) string {
+	for _, cl := range sl.chls.chls {
+		if cl.name == name && cl.user == "" && !cl.mpim {
+			return cl.id
+		}
+	}
+	return sl.all.FindChannelByName(name)
+}
+
+func (sl *slackLookup) GetEmoji(name string) (string, bool) {
+	return sl.emoji.Resolve(name)
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"github.com/nsf/termbox-go"
+	"github.com/g-dx/rosslyn/slack"
+)
+
+func TestCountsUnread(t *testing.T) {
+	msgs := []*Message{{Ts: "1503435956.000100"}, {Ts: "1503435956.000200"}}
+	shown := &Channel{name: "shown", msgs: msgs, pos: 1}
+	scrolled := &Channel{name: "scrolled", msgs: msgs, pos: 0}
+	hidden := &Channel{name: "hidden", msgs: msgs, pos: 1}
+
+	sv := NewSplitView(nil, nil, nil, 0, "")
+	sv.panes = []*ChannelView{{cl: shown}, {cl: scrolled}}
+	tests := []struct {
+		cl        *Channel
+		view      View
+		unreadChl *Channel
+		want      bool
+	}{
+		{shown, sv, nil, false},
+		{scrolled, sv, nil, true},
+		{hidden, sv, nil, true},
+		{shown, nil, nil, true},
+		{shown, sv, shown, true},
+		{shown, sv, scrolled, false},
+	}
+	for _, test := range tests {
+		ctrl := &controller{view: test.view, layout: sv, unreadChl: test.unreadChl}
+		if got := ctrl.countsUnread(test.cl); got != test.want {
+			t.Errorf("countsUnread(%v)\nGot : %v\nWant: %v", test.cl.name, got, test.want)
+		}
+	}
+}
+
+// Records presence changes, other calls panic
+type presenceApis struct {
+	slack.Apis
+	set []string
+	err error
+}
+
+func (a *presenceApis) SetPresence(presence string) error {
+	a.set = append(a.set, presence)
+	return a.err
+}
+
+type nullView struct{}
+
+func (nullView) OnKey(key termbox.Key, r rune) {}
+func (nullView) Draw(term Terminal)            {}
+
+func newPresenceController(cfg Config) *controller {
+	return &controller{cfg: cfg, status: &Status{}, userEvts: make(chan func(), 10), view: nullView{}}
+}
+
+func TestSetPresence(t *testing.T) {
+	tests := []struct {
+		presence, set string
+		err           error
+		want, status  string
+	}{
+		{"active", "away", nil, "away", ""},
+		{"away", "auto", nil, "active", ""},
+		{"active", "auto", nil, "active", ""},
+		{"active", "away", errors.New("timeout"), "active", "Unable to set presence for acme: timeout"},
+	}
+	for _, test := range tests {
+		ctrl := newPresenceController(Config{})
+		apis := &presenceApis{err: test.err}
+		tm := &team{name: "acme", apis: apis, presence: test.presence}
+		ctrl.setPresence([]*team{tm}, test.set)
+		(<-ctrl.userEvts)()
+		if !reflect.DeepEqual(apis.set, []string{test.set}) || tm.presence != test.want || ctrl.status.msg != test.status {
+			t.Errorf("setPresence(%q) from %q\nGot : %v, %q, %q\nWant: %v, %q, %q", test.set, test.presence,
+				apis.set, tm.presence, ctrl.status.msg, []string{test.set}, test.want, test.status)
+		}
+	}
+}
+
+func TestIdle(t *testing.T) {
+	ctrl := newPresenceController(Config{IdleTimeout: time.Hour})
+	activeApis, awayApis := &presenceApis{}, &presenceApis{}
+	active := &team{name: "active", apis: activeApis, presence: "active"}
+	away := &team{name: "away", apis: awayApis, presence: "away"}
+	ctrl.teams = []*team{active, away}
+
+	// Idling marks us away, except where we set it by hand
+	ctrl.onIdle()
+	(<-ctrl.userEvts)()
+	if !reflect.DeepEqual(ctrl.autoAway, []*team{active}) || active.presence != "away" || awayApis.set != nil {
+		t.Errorf("Got : %v, %q, %v\nWant: [active], away, []", ctrl.autoAway, active.presence, awayApis.set)
+	}
+
+	// Activity only marks back the teams idling marked away
+	ctrl.onActivity()
+	defer ctrl.idle.Stop()
+	(<-ctrl.userEvts)()
+	if ctrl.autoAway != nil || active.presence != "active" || away.presence != "away" {
+		t.Errorf("Got : %v, %q, %q\nWant: [], active, away", ctrl.autoAway, active.presence, away.presence)
+	}
+	if want := []string{"away", "auto"}; !reflect.DeepEqual(activeApis.set, want) {
+		t.Errorf("Got : %v\nWant: %v", activeApis.set, want)
+	}
+}
+
+func TestChangeTopic(t *testing.T) {
+	tests := []struct {
+		event          string
+		topic, purpose string
+	}{
+		{`{"subtype": "channel_topic", "topic": "Release day"}`, "Release day", "Tea"},
+		{`{"subtype": "group_topic", "topic": ""}`, "", "Tea"},
+		{`{"subtype": "channel_purpose", "purpose": "Biscuits"}`, "Deploys", "Biscuits"},
+		{`{"subtype": "channel_purpose", "purpose": ""}`, "Deploys", ""},
+		{`{"subtype": "group_purpose", "purpose": ""}`, "Deploys", ""},
+	}
+	for _, test := range tests {
+		var change slack.TopicChanged
+		if err := json.Unmarshal([]byte(test.event), &change); err != nil {
+			t.Fatal(err)
+		}
+		cl := &Channel{topic: "Deploys", purpose: "Tea"}
+		changeTopic(cl, &change)
+		if cl.topic != test.topic || cl.purpose != test.purpose {
+			t.Errorf("%v\nGot : %q, %q\nWant: %q, %q", test.event, cl.topic, cl.purpose, test.topic, test.purpose)
+		}
+	}
+}
+
+func TestAnyTeamHas(t *testing.T) {
+	acme := &team{chls: &ChannelList{chls: []*Channel{{id: "C1", name: "general"}, {id: "D1", name: "bob", user: "U2"}}}}
+	other := &team{chls: &ChannelList{chls: []*Channel{{id: "C2", name: "deploys"}}}}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"", false},
+		{"general", true},
+		{"#deploys", true},
+		{"genral", false},
+		{"bob", false}, // Not a channel
+	}
+	for _, test := range tests {
+		if got := anyTeamHas([]*team{acme, other}, test.name); got != test.want {
+			t.Errorf("anyTeamHas(%q)\nGot : %v\nWant: %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIdleWithoutKeys(t *testing.T) {
+	ctrl := newPresenceController(Config{IdleTimeout: 10 * time.Millisecond})
+	apis := &presenceApis{}
+	tm := &team{name: "acme", apis: apis, presence: "active"}
+	ctrl.teams = []*team{tm}
+
+	// As at startup, before any key is pressed
+	ctrl.resetIdle()
+	defer ctrl.idle.Stop()
+	(<-ctrl.userEvts)() // Idle
+	(<-ctrl.userEvts)() // Presence set
+	if tm.presence != "away" || !reflect.DeepEqual(apis.set, []string{"away"}) {
+		t.Errorf("Got : %q, %v\nWant: away, [away]", tm.presence, apis.set)
+	}
+
+	disabled := newPresenceController(Config{})
+	disabled.resetIdle()
+	if disabled.idle != nil {
+		t.Errorf("Got : timer\nWant: nil when disabled")
+	}
+}
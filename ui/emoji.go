@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"github.com/kyokomi/emoji/v2"
+)
+
+var flagRegex = regexp.MustCompile("^flag-([a-z]{2})$")
+
+// Skin tone modifiers (Fitzpatrick types 1-2 to 6), named as Slack does
+var skinTones = map[string]rune{
+	"skin-tone-2": '\U0001F3FB',
+	"skin-tone-3": '\U0001F3FC',
+	"skin-tone-4": '\U0001F3FD',
+	"skin-tone-5": '\U0001F3FE',
+	"skin-tone-6": '\U0001F3FF',
+}
+
+// Returns the Unicode sequence for a standard emoji shortcode (without colons), including aliases
+func lookupEmoji(name string) (string, bool) {
+	if e, ok := emoji.CodeMap()[":"+name+":"]; ok {
+		return e, true
+	}
+	// Country flags are pairs of regional indicators, e.g. "flag-gb"
+	if m := flagRegex.FindStringSubmatch(name); m != nil {
+		return string([]rune{'\U0001F1E6' + rune(m[1][0]-'a'), '\U0001F1E6' + rune(m[1][1]-'a')}), true
+	}
+	return "", false
+}
+
+// Applies a skin tone to an emoji. The modifier follows the first character, which is where it belongs in ZWJ
+// sequences too (e.g. "man-technologist"), replacing any emoji presentation selector.
+func applySkinTone(e, tone string) (string, bool) {
+	modifier, ok := skinTones[tone]
+	rs := []rune(e)
+	if !ok || len(rs) == 0 || rs[0] <= unicode.MaxASCII { // Unknown emoji are left as text
+		return "", false
+	}
+	rest := rs[1:]
+	if len(rest) > 0 && rest[0] == '\uFE0F' {
+		rest = rest[1:]
+	}
+	return string(rs[0]) + string(modifier) + string(rest), true
+}
+
+// Replaces any standard ":shortcode:" in s, e.g. a user's status emoji
+func emojize(s string) string {
+	if !strings.HasPrefix(s, ":") || !strings.HasSuffix(s, ":") || len(s) < 3 {
+		return s
+	}
+	if e, ok := lookupEmoji(s[1 : len(s)-1]); ok {
+		return e
+	}
+	return s
+}
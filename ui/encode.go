@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Resolves names typed by the user to Slack IDs, returning "" for unknown names
+type Resolver interface {
+	FindUser(name string) string
+	FindChannel(name string) string
+}
+
+// Converts text typed by the user into Slack's message format, the inverse of Formatter. Mentions of known users &
+// channels become links, "@here" etc. become notifications & control characters are escaped.
+// https://api.slack.com/docs/message-formatting#how_to_escape_characters
+func encodeMessage(text string, r Resolver) string {
+	var buf strings.Builder
+	rs := []rune(text)
+	for i := 0; i < len(rs); i++ {
+		switch c := rs[i]; {
+		case c == '&':
+			buf.WriteString("&amp;")
+		case c == '<':
+			buf.WriteString("&lt;")
+		case c == '>':
+			buf.WriteString("&gt;")
+		case (c == '@' || c == '#') && (i == 0 || !isNameRune(rs[i-1])):
+			name := mentionName(rs[i+1:])
+			if link := encodeMention(c, name, r); link != "" {
+				buf.WriteString(link)
+				i += len([]rune(name))
+				continue
+			}
+			buf.WriteRune(c)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String()
+}
+
+func encodeMention(c rune, name string, r Resolver) string {
+	if name == "" {
+		return ""
+	}
+	if c == '#' {
+		if id := r.FindChannel(name); id != "" {
+			return "<#" + id + ">"
+		}
+		return ""
+	}
+	switch name {
+	case "here", "channel", "everyone":
+		return "<!" + name + ">"
+	}
+	if id := r.FindUser(name); id != "" {
+		return "<@" + id + ">"
+	}
+	return ""
+}
+
+// Returns the name at the start of rs, excluding trailing punctuation (e.g. "@bob.")
+func mentionName(rs []rune) string {
+	n := 0
+	for n < len(rs) && isNameRune(rs[n]) {
+		n++
+	}
+	return strings.TrimRight(string(rs[:n]), ".-")
+}
+
+// Characters allowed in user & channel names
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"testing"
+)
+
+// Knows a single user & channel for encoding tests
+type nameLookup struct{}
+
+func (nl *nameLookup) FindUser(name string) string {
+	if name == "alice" || name == "john.smith" {
+		return "U" + name
+	}
+	return ""
+}
+
+func (nl *nameLookup) FindChannel(name string) string {
+	if name == "general" {
+		return "C1"
+	}
+	return ""
+}
+
+func (nl *nameLookup) GetUser(id string) string            { return id[1:] }
+func (nl *nameLookup) GetChannel(id string) string         { return "general" }
+func (nl *nameLookup) GetEmoji(name string) (string, bool) { return name, false }
+
+func TestEncodeMessage(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"a & b <c> d", "a &amp; b &lt;c&gt; d"},
+		{"&amp;", "&amp;amp;"},
+		{"hi @alice!", "hi <@Ualice>!"},
+		{"@john.smith.", "<@Ujohn.smith>."},
+		{"@bob is unknown", "@bob is unknown"},
+		{"email alice@alice.com", "email alice@alice.com"},
+		{"see #general, #unknown", "see <#C1>, #unknown"},
+		{"@here @channel @everyone", "<!here> <!channel> <!everyone>"},
+		{"@", "@"},
+	}
+
+	for _, data := range tests {
+		got := encodeMessage(data.s, &nameLookup{})
+		if got != data.want {
+			t.Errorf("encodeMessage(%q)\nGot : '%v'\nWant: '%v'", data.s, got, data.want)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []string{
+		"hi @alice & @here, see #general <now>",
+		"5 > 4 && 3 < 4",
+		"@bob isn't known",
+	}
+
+	fe := Formatter{ lookup: &nameLookup{} }
+	for _, s := range tests {
+		content, _ := fe.Format(encodeMessage(s, &nameLookup{}))
+		if string(content) != s {
+			t.Errorf("Round trip(%q)\nGot : '%v'", s, string(content))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"unicode"
+)
+
+const (
+	fuzzyMatchBonus       = 1
+	fuzzyConsecutiveBonus = 5
+	fuzzyWordStartBonus   = 10
+)
+
+// Matches pattern as a case-insensitive subsequence of s. Matches which are consecutive or begin words score higher
+// and gaps between matches are penalised. An empty pattern matches everything with a score of 0.
+func fuzzyScore(pattern, s string) (int, bool) {
+	ps := []rune(pattern)
+	if len(ps) == 0 {
+		return 0, true
+	}
+
+	score, pi, first, prev := 0, 0, -1, -2
+	var last rune
+	for i, r := range []rune(s) {
+		if pi < len(ps) && unicode.ToLower(r) == unicode.ToLower(ps[pi]) {
+			score += fuzzyMatchBonus
+			if i == prev+1 {
+				score += fuzzyConsecutiveBonus
+			}
+			if i == 0 || isWordSeparator(last) {
+				score += fuzzyWordStartBonus
+			}
+			if first == -1 {
+				first = i
+			}
+			prev = i
+			pi++
+		}
+		last = r
+	}
+	if pi < len(ps) {
+		return 0, false
+	}
+	return score - (prev - first + 1 - len(ps)), true
+}
+
+// Returns the best score of pattern against any of the strings
+func fuzzyBest(pattern string, ss ...string) (int, bool) {
+	best, found := 0, false
+	for _, s := range ss {
+		if score, ok := fuzzyScore(pattern, s); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r)
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		ok         bool
+	}{
+		{"", "anything", true},
+		{"gen", "general", true},
+		{"GEN", "general", true},
+		{"gnrl", "general", true},
+		{"jd", "John Doe", true},
+		{"xyz", "general", false},
+		{"generals", "general", false},
+		{"lareneg", "general", false},
+	}
+
+	for _, data := range tests {
+		_, ok := fuzzyScore(data.pattern, data.s)
+		if ok != data.ok {
+			t.Errorf("fuzzyScore(%q, %q)\nGot : '%v'\nWant: '%v'", data.pattern, data.s, ok, data.ok)
+		}
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		better, worse string
+	}{
+		{"gen", "general", "ugly-engine"}, // Prefix over later match
+		{"gen", "general", "gardening"},   // Consecutive over gaps
+		{"jd", "john doe", "ajdx"},        // Word starts over anything else
+		{"dop", "dev-ops", "developer"},
+		{"ops", "dev-ops", "dev-oops-s"}, // Fewer gaps
+	}
+
+	for _, data := range tests {
+		better, _ := fuzzyScore(data.pattern, data.better)
+		worse, _ := fuzzyScore(data.pattern, data.worse)
+		if better <= worse {
+			t.Errorf("fuzzyScore(%q): %q (%v) should beat %q (%v)", data.pattern, data.better, better, data.worse, worse)
+		}
+	}
+}
+
+func TestFuzzyBest(t *testing.T) {
+	score, ok := fuzzyBest("ali", "asmith", "Alice Smith", "alice@example.com")
+	want, _ := fuzzyScore("ali", "Alice Smith")
+	if !ok || score != want {
+		t.Errorf("Got '%v', Wanted: '%v'", score, want)
+	}
+	if _, ok := fuzzyBest("zzz", "asmith", "Alice Smith"); ok {
+		t.Errorf("Got 'true', Wanted: 'false'")
+	}
+}
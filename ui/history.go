@@ -0,0 +1,78 @@
+package ui
+
+const maxHistory = 50
+
+// Channels visited before & after the current one, like a web browser's back & forward buttons
+type navHistory struct {
+	back    []*Channel // Most recent last
+	forward []*Channel // Next last
+}
+
+// Records moving from one channel to another, discarding any forward history
+func (nh *navHistory) visit(from, to *Channel) {
+	if from == nil || from == to {
+		return
+	}
+	nh.back = pushChannel(nh.back, from)
+	nh.forward = nil
+}
+
+// Returns the previous channel, or nil if there isn't one
+func (nh *navHistory) goBack(cur *Channel) *Channel {
+	if len(nh.back) == 0 {
+		return nil
+	}
+	cl := nh.back[len(nh.back)-1]
+	nh.back = nh.back[:len(nh.back)-1]
+	nh.forward = pushChannel(nh.forward, cur)
+	return cl
+}
+
+// Returns the next channel, or nil if there isn't one
+func (nh *navHistory) goForward(cur *Channel) *Channel {
+	if len(nh.forward) == 0 {
+		return nil
+	}
+	cl := nh.forward[len(nh.forward)-1]
+	nh.forward = nh.forward[:len(nh.forward)-1]
+	nh.back = pushChannel(nh.back, cur)
+	return cl
+}
+
+// Returns the channel visited last, or nil if there isn't one, & puts the current channel in its place. Switching
+// back & forth between two channels doesn't grow the history.
+func (nh *navHistory) swap(cur *Channel) *Channel {
+	if len(nh.back) == 0 || cur == nil {
+		return nil
+	}
+	cl := nh.back[len(nh.back)-1]
+	nh.back[len(nh.back)-1] = cur
+	nh.forward = nil
+	return cl
+}
+
+// Forgets a channel, e.g. after leaving it
+func (nh *navHistory) remove(cl *Channel) {
+	nh.back = dropChannel(nh.back, cl)
+	nh.forward = dropChannel(nh.forward, cl)
+}
+
+func pushChannel(chls []*Channel, cl *Channel) []*Channel {
+	if len(chls) > 0 && chls[len(chls)-1] == cl {
+		return chls
+	}
+	if len(chls) == maxHistory {
+		chls = chls[1:]
+	}
+	return append(chls, cl)
+}
+
+func dropChannel(chls []*Channel, cl *Channel) []*Channel {
+	result := chls[:0]
+	for _, c := range chls {
+		if c != cl && (len(result) == 0 || result[len(result)-1] != c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
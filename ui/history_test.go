@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestNavHistory(t *testing.T) {
+	a, b, c := &Channel{name: "a"}, &Channel{name: "b"}, &Channel{name: "c"}
+	var nh navHistory
+	nh.visit(nil, a)
+	nh.visit(a, b)
+	nh.visit(b, c)
+
+	cur := c
+	steps := []struct {
+		step string
+		want *Channel
+	}{
+		{"back", b},
+		{"back", a},
+		{"back", nil},
+		{"forward", b},
+		{"forward", c},
+		{"forward", nil},
+		{"swap", b},
+	}
+	for _, s := range steps {
+		var got *Channel
+		switch s.step {
+		case "back":
+			got = nh.goBack(cur)
+		case "forward":
+			got = nh.goForward(cur)
+		case "swap":
+			got = nh.swap(cur)
+		}
+		if got != s.want {
+			t.Fatalf("%v - Got : %v\nWant: %v", s.step, got, s.want)
+		}
+		if got != nil {
+			cur = got
+		}
+	}
+
+	// Visiting discards forward history
+	nh.goBack(cur)
+	nh.visit(b, a)
+	if got := nh.goForward(a); got != nil {
+		t.Errorf("Got : %v\nWant: nil", got)
+	}
+
+	// Removed channels are skipped
+	nh.remove(b)
+	if got := nh.goBack(c); got != a {
+		t.Errorf("Got : %v\nWant: a", got)
+	}
+}
+
+func TestNavHistorySwap(t *testing.T) {
+	a, b, c := &Channel{name: "a"}, &Channel{name: "b"}, &Channel{name: "c"}
+	var nh navHistory
+	if got := nh.swap(a); got != nil {
+		t.Errorf("Got : %v\nWant: nil", got)
+	}
+	nh.visit(a, b)
+	nh.visit(b, c)
+
+	// Toggling between two channels keeps the history the same size
+	cur := c
+	for i, want := range []*Channel{b, c, b, c, b} {
+		got := nh.swap(cur)
+		if got != want {
+			t.Fatalf("%v - Got : %v\nWant: %v", i, got, want)
+		}
+		cur = got
+	}
+	if len(nh.back) != 2 {
+		t.Errorf("Got : %v\nWant: 2 channels back", len(nh.back))
+	}
+
+	// Going back skips the toggling
+	if got := nh.goBack(cur); got != c {
+		t.Errorf("Got : %v\nWant: c", got)
+	}
+	if got := nh.goBack(c); got != a {
+		t.Errorf("Got : %v\nWant: a", got)
+	}
+}
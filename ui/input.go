@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"unicode/utf8"
 	"github.com/nsf/termbox-go"
 	"github.com/mattn/go-runewidth"
@@ -9,6 +10,7 @@ import (
 
 const preferred_horizontal_threshold = 5
 const tabstop_length = 8
+const edit_prompt = "➤ "
 
 type line struct {
 	buf []byte
@@ -32,7 +34,32 @@ type EditBox struct {
 }
 
 func (eb *EditBox) GetText() string {
-	return string(eb.text[3:])
+	return strings.TrimPrefix(string(eb.text), edit_prompt)
+}
+
+// Replaces the text & moves the cursor to the end
+func (eb *EditBox) SetText(s string) {
+	eb.Clear()
+	for _, r := range s {
+		eb.InsertRune(r)
+	}
+}
+
+// Returns the text from the start of the word under the cursor up to the cursor & its offset within GetText()
+func (eb *EditBox) WordBeforeCursor() (string, int) {
+	before := strings.TrimPrefix(string(eb.text[:eb.cursor_boffset]), edit_prompt)
+	i := strings.LastIndexAny(before, " \t\n") + 1
+	return before[i:], i
+}
+
+// Replaces the text from offset (within GetText()) up to the cursor
+func (eb *EditBox) ReplaceBeforeCursor(offset int, s string) {
+	start := len(edit_prompt) + offset
+	eb.text = byte_slice_remove(eb.text, start, eb.cursor_boffset)
+	eb.MoveCursorTo(start)
+	for _, r := range s {
+		eb.InsertRune(r)
+	}
 }
 
 // Draws the EditBox in the given location, 'h' is not used at the moment
@@ -126,7 +153,7 @@ func (eb *EditBox) RuneBeforeCursor() (rune, int) {
 }
 
 func (eb *EditBox) MoveCursorOneRuneBackward() {
-	if eb.cursor_boffset == 0 {
+	if eb.cursor_boffset <= len(edit_prompt) {
 		return
 	}
 	_, size := eb.RuneBeforeCursor()
@@ -142,7 +169,7 @@ func (eb *EditBox) MoveCursorOneRuneForward() {
 }
 
 func (eb *EditBox) MoveCursorToBeginningOfTheLine() {
-	eb.MoveCursorTo(0)
+	eb.MoveCursorTo(len(edit_prompt))
 }
 
 func (eb *EditBox) MoveCursorToEndOfTheLine() {
@@ -150,7 +177,7 @@ func (eb *EditBox) MoveCursorToEndOfTheLine() {
 }
 
 func (eb *EditBox) DeleteRuneBackward() {
-	if eb.cursor_boffset == 0 {
+	if eb.cursor_boffset <= len(edit_prompt) {
 		return
 	}
 
@@ -173,13 +200,12 @@ func (eb *EditBox) DeleteTheRestOfTheLine() {
 
 
 func (eb *EditBox) Clear() {
-	eb.text = nil
 	eb.cursor_boffset = 0
 	eb.cursor_voffset = 0
 	eb.line_voffset = 0
 	eb.placeholder = true
-	eb.InsertRune('➤')
-	eb.InsertRune(' ')
+	eb.text = []byte(edit_prompt)
+	eb.MoveCursorTo(len(eb.text))
 }
 
 func (eb *EditBox) InsertRune(r rune) {
@@ -189,6 +215,12 @@ func (eb *EditBox) InsertRune(r rune) {
 	eb.MoveCursorOneRuneForward()
 }
 
+// Returns the visual column of an offset within GetText(), see CursorX()
+func (eb *EditBox) ColumnOf(offset int) int {
+	voffset, _ := voffset_coffset(eb.text, len(edit_prompt)+offset)
+	return voffset - eb.line_voffset
+}
+
 // Please, keep in mind that cursor depends on the value of line_voffset, which
 // is being set on Draw() call, so.. call this method after Draw() one.
 func (eb *EditBox) CursorX() int {
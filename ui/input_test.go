@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestEditBoxCompletion(t *testing.T) {
+	var eb EditBox
+	eb.SetText("hi @bo")
+	if got := eb.GetText(); got != "hi @bo" {
+		t.Errorf("GetText()\nGot : '%v'\nWant: '%v'", got, "hi @bo")
+	}
+
+	word, offset := eb.WordBeforeCursor()
+	if word != "@bo" || offset != 3 {
+		t.Errorf("WordBeforeCursor()\nGot : '%v', %v\nWant: '%v', %v", word, offset, "@bo", 3)
+	}
+
+	eb.ReplaceBeforeCursor(offset, "@bobby ")
+	if got := eb.GetText(); got != "hi @bobby " {
+		t.Errorf("ReplaceBeforeCursor()\nGot : '%v'\nWant: '%v'", got, "hi @bobby ")
+	}
+
+	// Cursor can't move into the prompt
+	eb.MoveCursorToBeginningOfTheLine()
+	eb.DeleteRuneBackward()
+	if got := eb.GetText(); got != "hi @bobby " {
+		t.Errorf("DeleteRuneBackward()\nGot : '%v'\nWant: '%v'", got, "hi @bobby ")
+	}
+}
@@ -0,0 +1,568 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"github.com/nsf/termbox-go"
+)
+
+// Parts of the UI which keys can be bound in. Several are active at once, e.g. a key bound in "channel" is found before
+// the same key bound in "global".
+const (
+	contextGlobal     = "global"
+	contextLayout     = "layout"     // Sidebar & message panes
+	contextSidebar    = "sidebar"    // Focused sidebar
+	contextChannel    = "channel"    // Focused message pane
+	contextEditor     = "editor"     // Message editor in the focused message pane
+	contextCompletion = "completion" // Completion popup in the focused message pane
+	contextMembers    = "members"    // Focused member panel
+	contextChannels   = "channels"   // Channel selection
+	contextTeams      = "teams"      // Workspace selection
+	contextUsers      = "users"      // User selection
+	contextHelp       = "help"       // Command & key binding help
+)
+
+// Names of the keys which aren't runes. Ctrl+letter is handled separately.
+var keyNames = map[string]termbox.Key{
+	"enter":      termbox.KeyEnter,
+	"esc":        termbox.KeyEsc,
+	"tab":        termbox.KeyTab,
+	"space":      termbox.KeySpace,
+	"backspace":  termbox.KeyBackspace,
+	"delete":     termbox.KeyDelete,
+	"insert":     termbox.KeyInsert,
+	"home":       termbox.KeyHome,
+	"end":        termbox.KeyEnd,
+	"pgup":       termbox.KeyPgup,
+	"pgdn":       termbox.KeyPgdn,
+	"up":         termbox.KeyArrowUp,
+	"down":       termbox.KeyArrowDown,
+	"left":       termbox.KeyArrowLeft,
+	"right":      termbox.KeyArrowRight,
+	"ctrl-space": termbox.KeyCtrlSpace,
+	"ctrl-\\":    termbox.KeyCtrlBackslash,
+	"ctrl-]":     termbox.KeyCtrlRsqBracket,
+	"ctrl-^":     termbox.KeyCtrl6,
+	"ctrl-_":     termbox.KeyCtrlUnderscore,
+	"f1":         termbox.KeyF1,
+	"f2":         termbox.KeyF2,
+	"f3":         termbox.KeyF3,
+	"f4":         termbox.KeyF4,
+	"f5":         termbox.KeyF5,
+	"f6":         termbox.KeyF6,
+	"f7":         termbox.KeyF7,
+	"f8":         termbox.KeyF8,
+	"f9":         termbox.KeyF9,
+	"f10":        termbox.KeyF10,
+	"f11":        termbox.KeyF11,
+	"f12":        termbox.KeyF12,
+}
+
+// Parses a key such as "a", "ctrl-k", "alt-left" or "f1"
+func parseKey(s string) (keyEvent, error) {
+	var k keyEvent
+	name := s
+	if strings.HasPrefix(strings.ToLower(name), "alt-") && len(name) > 4 {
+		k.alt, name = true, name[4:]
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		k.ch, _ = utf8.DecodeRuneInString(name)
+		return k, nil
+	}
+	name = strings.ToLower(name)
+	if key, ok := keyNames[name]; ok {
+		k.key = key
+		return k, nil
+	}
+	if len(name) == 6 && strings.HasPrefix(name, "ctrl-") && name[5] >= 'a' && name[5] <= 'z' {
+		k.key = termbox.KeyCtrlA + termbox.Key(name[5]-'a')
+		return k, nil
+	}
+	return k, fmt.Errorf("unknown key '%v'", s)
+}
+
+// Parses space separated keys, e.g. "ctrl-w v"
+func parseKeys(s string) ([]keyEvent, error) {
+	var keys []keyEvent
+	for _, f := range strings.Fields(s) {
+		k, err := parseKey(f)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys given")
+	}
+	return keys, nil
+}
+
+func (k keyEvent) String() string {
+	prefix := ""
+	if k.alt {
+		prefix = "alt-"
+	}
+	if k.ch != 0 {
+		return prefix + string(k.ch)
+	}
+	for name, key := range keyNames {
+		if key == k.key {
+			return prefix + name
+		}
+	}
+	if k.key >= termbox.KeyCtrlA && k.key <= termbox.KeyCtrlZ {
+		return prefix + "ctrl-" + string(rune('a' + k.key - termbox.KeyCtrlA))
+	}
+	return prefix + fmt.Sprintf("<%d>", k.key)
+}
+
+func formatKeys(keys []keyEvent) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// Terminals send either of two codes for backspace
+func (k keyEvent) normalise() keyEvent {
+	if k.key == termbox.KeyBackspace2 {
+		k.key = termbox.KeyBackspace
+	}
+	return k
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Something keys can be bound to, which is only available in its context
+type action struct {
+	name    string
+	context string
+	keys    []string // Bound by default, e.g. "ctrl-w v"
+	help    string
+	run     func(ctrl *controller)
+}
+
+type actionRegistry struct {
+	actions []*action // In the order they are listed in help
+}
+
+func newActionRegistry() *actionRegistry {
+	ar := &actionRegistry{}
+
+	// Global
+	ar.register(&action{name: "quit", context: contextGlobal, keys: []string{"ctrl-q"}, help: "Quit", run: func(ctrl *controller) {
+		ctrl.quit()
+	}})
+	ar.register(&action{name: "help", context: contextGlobal, keys: []string{"f1"}, help: "List the active key bindings", run: func(ctrl *controller) {
+		ctrl.view = NewKeyHelpView(ctrl, ctrl.keymap.active(ctrl.keyContexts()))
+		ctrl.Redraw()
+	}})
+	ar.register(&action{name: "back", context: contextGlobal, keys: []string{"alt-left"}, help: "Go back to the previous channel", run: func(ctrl *controller) {
+		ctrl.showChannel(ctrl.history.goBack(ctrl.chl))
+	}})
+	ar.register(&action{name: "forward", context: contextGlobal, keys: []string{"alt-right"}, help: "Go forward to the next channel", run: func(ctrl *controller) {
+		ctrl.showChannel(ctrl.history.goForward(ctrl.chl))
+	}})
+	ar.register(&action{name: "last-channel", context: contextGlobal, keys: []string{"ctrl-^"}, help: "Switch to the last channel", run: func(ctrl *controller) {
+		if cl := ctrl.history.swap(ctrl.chl); cl != nil {
+			ctrl.view = ctrl.layout
+			ctrl.showChannel(cl)
+		}
+	}})
+
+	// Layout
+	ar.register(&action{name: "toggle-sidebar", context: contextLayout, keys: []string{"ctrl-l"}, help: "Focus or unfocus the sidebar", run: func(ctrl *controller) {
+		ctrl.layout.focusSidebar(!ctrl.layout.sidebar.focused)
+	}})
+	ar.register(&action{name: "toggle-members", context: contextLayout, keys: []string{"ctrl-u"}, help: "Show, focus or hide the member panel", run: func(ctrl *controller) {
+		ctrl.layout.main().toggleMembers()
+	}})
+	ar.register(&action{name: "split-pane", context: contextLayout, keys: []string{"ctrl-w v"}, help: "Open the channel in a new pane", run: func(ctrl *controller) {
+		ctrl.layout.split()
+	}})
+	ar.register(&action{name: "close-pane", context: contextLayout, keys: []string{"ctrl-w c"}, help: "Close the focused pane", run: func(ctrl *controller) {
+		ctrl.layout.close()
+	}})
+	ar.register(&action{name: "next-pane", context: contextLayout, keys: []string{"ctrl-w w"}, help: "Focus the next pane", run: func(ctrl *controller) {
+		ctrl.layout.focusPane((ctrl.layout.focus + 1) % len(ctrl.layout.panes))
+	}})
+	ar.register(&action{name: "rotate-panes", context: contextLayout, keys: []string{"ctrl-w r"}, help: "Arrange panes side by side or stacked", run: func(ctrl *controller) {
+		ctrl.layout.stacked = !ctrl.layout.stacked
+		ctrl.Redraw()
+	}})
+
+	// Sidebar
+	ar.register(&action{name: "sidebar-narrower", context: contextSidebar, keys: []string{"<"}, help: "Make the sidebar narrower", run: func(ctrl *controller) {
+		ctrl.layout.resize(-1)
+	}})
+	ar.register(&action{name: "sidebar-wider", context: contextSidebar, keys: []string{">"}, help: "Make the sidebar wider", run: func(ctrl *controller) {
+		ctrl.layout.resize(1)
+	}})
+	ar.register(&action{name: "star-channel", context: contextSidebar, keys: []string{"ctrl-s"}, help: "Star or unstar the channel", run: func(ctrl *controller) {
+		ctrl.sidebar.toggleStar()
+	}})
+	ar.register(&action{name: "sidebar-sort", context: contextSidebar, keys: []string{"ctrl-o"}, help: "Change the order of channels", run: func(ctrl *controller) {
+		ctrl.sidebar.order = ctrl.sidebar.order.next()
+		ctrl.sidebar.invalidate()
+		ctrl.Redraw()
+	}})
+	ar.register(&action{name: "sidebar-up", context: contextSidebar, keys: []string{"up"}, help: "Select the previous row", run: func(ctrl *controller) {
+		ctrl.sidebar.move(-1)
+	}})
+	ar.register(&action{name: "sidebar-down", context: contextSidebar, keys: []string{"down"}, help: "Select the next row", run: func(ctrl *controller) {
+		ctrl.sidebar.move(1)
+	}})
+	ar.register(&action{name: "sidebar-open", context: contextSidebar, keys: []string{"enter"}, help: "Switch to the channel, or collapse or expand the section", run: func(ctrl *controller) {
+		ctrl.sidebar.open()
+	}})
+	ar.register(&action{name: "sidebar-collapse", context: contextSidebar, keys: []string{"space"}, help: "Collapse or expand the section", run: func(ctrl *controller) {
+		ctrl.sidebar.toggleSection()
+	}})
+	ar.register(&action{name: "sidebar-close", context: contextSidebar, keys: []string{"esc"}, help: "Return to the messages", run: func(ctrl *controller) {
+		ctrl.layout.focusSidebar(false)
+	}})
+
+	// Channel
+	ar.register(&action{name: "select-channel", context: contextChannel, keys: []string{"ctrl-k"}, help: "Switch to another channel", run: func(ctrl *controller) {
+		ctrl.SelectChannel()
+	}})
+	ar.register(&action{name: "select-team", context: contextChannel, keys: []string{"ctrl-t"}, help: "Switch to another workspace", run: func(ctrl *controller) {
+		ctrl.SelectTeam()
+	}})
+	ar.register(&action{name: "select-users", context: contextChannel, keys: []string{"ctrl-n"}, help: "Start a conversation", run: func(ctrl *controller) {
+		ctrl.SelectUsers()
+	}})
+	ar.register(&action{name: "mark-unread", context: contextChannel, keys: []string{"ctrl-r"}, help: "Mark the selected message unread", run: func(ctrl *controller) {
+		ctrl.layout.main().markUnread()
+	}})
+	ar.register(&action{name: "scroll-up", context: contextChannel, keys: []string{"up"}, help: "Select the previous message", run: func(ctrl *controller) {
+		ctrl.layout.main().up()
+	}})
+	ar.register(&action{name: "scroll-down", context: contextChannel, keys: []string{"down"}, help: "Select the next message", run: func(ctrl *controller) {
+		ctrl.layout.main().down()
+	}})
+	ar.register(&action{name: "page-up", context: contextChannel, keys: []string{"pgup"}, help: "Scroll up a page", run: func(ctrl *controller) {
+		ctrl.layout.main().pageUp()
+	}})
+	ar.register(&action{name: "page-down", context: contextChannel, keys: []string{"pgdn"}, help: "Scroll down a page", run: func(ctrl *controller) {
+		ctrl.layout.main().pageDown()
+	}})
+
+	// Editor
+	ar.register(&action{name: "send", context: contextEditor, keys: []string{"enter"}, help: "Send the message or run the command", run: func(ctrl *controller) {
+		ctrl.layout.main().send()
+	}})
+	ar.register(&action{name: "complete-command", context: contextEditor, keys: []string{"tab"}, help: "Complete the command, otherwise insert a tab", run: func(ctrl *controller) {
+		ctrl.layout.main().completeCommand()
+	}})
+	ar.register(&action{name: "cursor-left", context: contextEditor, keys: []string{"left", "ctrl-b"}, help: "Move the cursor left", run: func(ctrl *controller) {
+		ctrl.layout.main().edit((*EditBox).MoveCursorOneRuneBackward)
+	}})
+	ar.register(&action{name: "cursor-right", context: contextEditor, keys: []string{"right", "ctrl-f"}, help: "Move the cursor right", run: func(ctrl *controller) {
+		ctrl.layout.main().edit((*EditBox).MoveCursorOneRuneForward)
+	}})
+	ar.register(&action{name: "line-start", context: contextEditor, keys: []string{"home", "ctrl-a"}, help: "Move the cursor to the start", run: func(ctrl *controller) {
+		ctrl.layout.main().edit((*EditBox).MoveCursorToBeginningOfTheLine)
+	}})
+	ar.register(&action{name: "line-end", context: contextEditor, keys: []string{"end", "ctrl-e"}, help: "Move the cursor to the end", run: func(ctrl *controller) {
+		ctrl.layout.main().edit((*EditBox).MoveCursorToEndOfTheLine)
+	}})
+	ar.register(&action{name: "delete-backward", context: contextEditor, keys: []string{"backspace"}, help: "Delete the character before the cursor", run: func(ctrl *controller) {
+		ctrl.layout.main().edit((*EditBox).DeleteRuneBackward)
+	}})
+	ar.register(&action{name: "delete-forward", context: contextEditor, keys: []string{"delete", "ctrl-d"}, help: "Delete the character under the cursor", run: func(ctrl *controller) {
+		ctrl.layout.main().edit((*EditBox).DeleteRuneForward)
+	}})
+
+	// Completion
+	ar.register(&action{name: "completion-next", context: contextCompletion, keys: []string{"tab"}, help: "Select the next completion, or the only one", run: func(ctrl *controller) {
+		cv := ctrl.layout.main()
+		if len(cv.completions.matches) == 1 {
+			cv.acceptCompletion()
+		} else {
+			cv.completions.down()
+		}
+		ctrl.Redraw()
+	}})
+	ar.register(&action{name: "completion-down", context: contextCompletion, keys: []string{"down"}, help: "Select the next completion", run: func(ctrl *controller) {
+		ctrl.layout.main().completions.down()
+		ctrl.Redraw()
+	}})
+	ar.register(&action{name: "completion-up", context: contextCompletion, keys: []string{"up"}, help: "Select the previous completion", run: func(ctrl *controller) {
+		ctrl.layout.main().completions.up()
+		ctrl.Redraw()
+	}})
+	ar.register(&action{name: "completion-accept", context: contextCompletion, keys: []string{"enter"}, help: "Use the selected completion, or send if it is already typed", run: func(ctrl *controller) {
+		cv := ctrl.layout.main()
+		if cv.completions.current().text == cv.completions.word {
+			cv.send() // Nothing left to complete, e.g. "/away"
+		} else {
+			cv.acceptCompletion()
+		}
+		ctrl.Redraw()
+	}})
+	ar.register(&action{name: "completion-dismiss", context: contextCompletion, keys: []string{"esc"}, help: "Hide completions", run: func(ctrl *controller) {
+		ctrl.layout.main().dismissCompletions()
+		ctrl.Redraw()
+	}})
+
+	// Channel selection
+	ar.register(&action{name: "channels-up", context: contextChannels, keys: []string{"up"}, help: "Select the previous channel", run: func(ctrl *controller) {
+		ctrl.view.(*ChannelSelectionView).move(-1)
+	}})
+	ar.register(&action{name: "channels-down", context: contextChannels, keys: []string{"down"}, help: "Select the next channel", run: func(ctrl *controller) {
+		ctrl.view.(*ChannelSelectionView).move(1)
+	}})
+	ar.register(&action{name: "channels-open", context: contextChannels, keys: []string{"enter"}, help: "Switch to the selected channel", run: func(ctrl *controller) {
+		ctrl.view.(*ChannelSelectionView).open()
+	}})
+	ar.register(&action{name: "channels-close", context: contextChannels, keys: []string{"esc"}, help: "Return to the current channel", run: func(ctrl *controller) {
+		ctrl.SwitchChannel(nil)
+	}})
+
+	// Member panel
+	ar.register(&action{name: "members-up", context: contextMembers, keys: []string{"up"}, help: "Select the previous member", run: func(ctrl *controller) {
+		ctrl.layout.main().members.move(-1)
+	}})
+	ar.register(&action{name: "members-down", context: contextMembers, keys: []string{"down"}, help: "Select the next member", run: func(ctrl *controller) {
+		ctrl.layout.main().members.move(1)
+	}})
+	ar.register(&action{name: "members-open", context: contextMembers, keys: []string{"enter"}, help: "Start a conversation with the member", run: func(ctrl *controller) {
+		ctrl.layout.main().members.open()
+	}})
+	ar.register(&action{name: "members-profile", context: contextMembers, keys: []string{"ctrl-p"}, help: "Show or hide the member's profile", run: func(ctrl *controller) {
+		ctrl.layout.main().members.toggleCard()
+	}})
+	ar.register(&action{name: "members-close", context: contextMembers, keys: []string{"esc"}, help: "Hide the profile, otherwise return to the messages", run: func(ctrl *controller) {
+		ctrl.layout.main().members.close()
+	}})
+
+	// Workspace selection
+	ar.register(&action{name: "teams-up", context: contextTeams, keys: []string{"up"}, help: "Select the previous workspace", run: func(ctrl *controller) {
+		ctrl.teamsView.move(-1)
+	}})
+	ar.register(&action{name: "teams-down", context: contextTeams, keys: []string{"down"}, help: "Select the next workspace", run: func(ctrl *controller) {
+		ctrl.teamsView.move(1)
+	}})
+	ar.register(&action{name: "teams-open", context: contextTeams, keys: []string{"enter"}, help: "Switch to the selected workspace", run: func(ctrl *controller) {
+		ctrl.teamsView.open()
+	}})
+	ar.register(&action{name: "teams-close", context: contextTeams, keys: []string{"esc"}, help: "Return to the current channel", run: func(ctrl *controller) {
+		ctrl.SwitchChannel(nil)
+	}})
+
+	// User selection
+	ar.register(&action{name: "users-up", context: contextUsers, keys: []string{"up"}, help: "Select the previous user", run: func(ctrl *controller) {
+		ctrl.view.(*UserSelectionView).move(-1)
+	}})
+	ar.register(&action{name: "users-down", context: contextUsers, keys: []string{"down"}, help: "Select the next user", run: func(ctrl *controller) {
+		ctrl.view.(*UserSelectionView).move(1)
+	}})
+	ar.register(&action{name: "users-toggle", context: contextUsers, keys: []string{"tab"}, help: "Add or remove the user", run: func(ctrl *controller) {
+		ctrl.view.(*UserSelectionView).toggle()
+	}})
+	ar.register(&action{name: "users-open", context: contextUsers, keys: []string{"enter"}, help: "Start a conversation with the chosen users", run: func(ctrl *controller) {
+		ctrl.view.(*UserSelectionView).open()
+	}})
+	ar.register(&action{name: "users-close", context: contextUsers, keys: []string{"esc"}, help: "Return to the current channel", run: func(ctrl *controller) {
+		ctrl.SwitchChannel(nil)
+	}})
+
+	// Help
+	ar.register(&action{name: "help-up", context: contextHelp, keys: []string{"up"}, help: "Scroll up", run: func(ctrl *controller) {
+		ctrl.view.(helpView).scroll(-1)
+	}})
+	ar.register(&action{name: "help-down", context: contextHelp, keys: []string{"down"}, help: "Scroll down", run: func(ctrl *controller) {
+		ctrl.view.(helpView).scroll(1)
+	}})
+	ar.register(&action{name: "help-close", context: contextHelp, keys: []string{"esc", "enter"}, help: "Return to the current channel", run: func(ctrl *controller) {
+		ctrl.SwitchChannel(nil)
+	}})
+	return ar
+}
+
+func (ar *actionRegistry) register(a *action) {
+	ar.actions = append(ar.actions, a)
+}
+
+func (ar *actionRegistry) find(name string) *action {
+	for _, a := range ar.actions {
+		if a.name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Keys bound to an action in its context
+type binding struct {
+	keys   []keyEvent
+	action *action
+}
+
+// Key bindings for every context
+type Keymap struct {
+	actions  *actionRegistry
+	bindings map[string][]binding // By context
+}
+
+// Returns the default bindings of every action
+func DefaultKeymap() *Keymap {
+	km := &Keymap{actions: newActionRegistry(), bindings: make(map[string][]binding)}
+	for _, a := range km.actions.actions {
+		for _, s := range a.keys {
+			keys, err := parseKeys(s)
+			if err != nil {
+				panic(fmt.Sprintf("action '%v': %v", a.name, err))
+			}
+			km.bind(keys, a)
+		}
+	}
+	if err := km.validate(); err != nil {
+		panic(err)
+	}
+	return km
+}
+
+// Returns the default bindings with those of some actions replaced, e.g. "split-pane": {"ctrl-x 3"}. An action given
+// no keys is unbound.
+func NewKeymap(bindings map[string][]string) (*Keymap, error) {
+	km := DefaultKeymap()
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Report errors consistently
+	for _, name := range names {
+		a := km.actions.find(name)
+		if a == nil {
+			return nil, fmt.Errorf("unknown action '%v'", name)
+		}
+		km.unbindAction(a)
+		for _, s := range bindings[name] {
+			keys, err := parseKeys(s)
+			if err != nil {
+				return nil, fmt.Errorf("action '%v': %v", name, err)
+			}
+			km.bind(keys, a)
+		}
+	}
+	if err := km.validate(); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+func (km *Keymap) bind(keys []keyEvent, a *action) {
+	for _, b := range km.bindings[a.context] {
+		if b.action == a && keysEqual(b.keys, keys) {
+			return // Already bound
+		}
+	}
+	km.bindings[a.context] = append(km.bindings[a.context], binding{keys, a})
+}
+
+// Checks every binding can be reached, i.e. no keys in a context are bound twice or begin a longer binding
+func (km *Keymap) validate() error {
+	contexts := make([]string, 0, len(km.bindings))
+	for context := range km.bindings {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts) // Report errors consistently
+	for _, context := range contexts {
+		bs := km.bindings[context]
+		for i, a := range bs {
+			for _, b := range bs[i+1:] {
+				if len(b.keys) < len(a.keys) {
+					a, b = b, a
+				}
+				switch {
+				case keysEqual(a.keys, b.keys):
+					return fmt.Errorf("%v is bound to both '%v' & '%v'", formatKeys(a.keys), a.action.name, b.action.name)
+				case keysEqual(a.keys, b.keys[:len(a.keys)]):
+					return fmt.Errorf("%v of '%v' can't be pressed as %v is bound to '%v'", formatKeys(b.keys),
+						b.action.name, formatKeys(a.keys), a.action.name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Removes every binding of an action
+func (km *Keymap) unbindAction(a *action) {
+	bs := km.bindings[a.context][:0]
+	for _, b := range km.bindings[a.context] {
+		if b.action != a {
+			bs = append(bs, b)
+		}
+	}
+	km.bindings[a.context] = bs
+}
+
+// Finds the action bound to the keys in the first of the contexts to bind them. Returns true instead if the keys begin
+// a longer binding.
+func (km *Keymap) lookup(contexts []string, keys []keyEvent) (*action, bool) {
+	for _, context := range contexts {
+		prefix := false
+		for _, b := range km.bindings[context] {
+			switch {
+			case len(b.keys) < len(keys) || !keysEqual(b.keys[:len(keys)], keys):
+				continue
+			case len(b.keys) == len(keys):
+				return b.action, false
+			default:
+				prefix = true
+			}
+		}
+		if prefix {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+func keysEqual(a, b []keyEvent) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
+
+// A context & its bindings, for help
+type keyHelp struct {
+	context  string
+	bindings []binding
+}
+
+// Returns the bindings of the contexts which aren't shadowed by an earlier context, in the order actions are listed
+func (km *Keymap) active(contexts []string) []keyHelp {
+	order := make(map[*action]int)
+	for i, a := range km.actions.actions {
+		order[a] = i
+	}
+
+	var help []keyHelp
+	seen := make(map[string]bool)
+	for _, context := range contexts {
+		var bs []binding
+		for _, b := range km.bindings[context] {
+			if name := formatKeys(b.keys); !seen[name] {
+				seen[name] = true
+				bs = append(bs, b)
+			}
+		}
+		sort.SliceStable(bs, func(i, j int) bool { return order[bs[i].action] < order[bs[j].action] })
+		if len(bs) > 0 {
+			help = append(help, keyHelp{context, bs})
+		}
+	}
+	return help
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"testing"
+	"github.com/nsf/termbox-go"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want keyEvent
+		name string // Formatted, if different to in
+	}{
+		{"a", keyEvent{ch: 'a'}, ""},
+		{"<", keyEvent{ch: '<'}, ""},
+		{"ctrl-k", keyEvent{key: termbox.KeyCtrlK}, ""},
+		{"Ctrl-K", keyEvent{key: termbox.KeyCtrlK}, "ctrl-k"},
+		{"ctrl-^", keyEvent{key: termbox.KeyCtrl6}, ""},
+		{"alt-left", keyEvent{key: termbox.KeyArrowLeft, alt: true}, ""},
+		{"alt-x", keyEvent{ch: 'x', alt: true}, ""},
+		{"f1", keyEvent{key: termbox.KeyF1}, ""},
+		{"backspace", keyEvent{key: termbox.KeyBackspace}, ""},
+		{"ctrl-h", keyEvent{key: termbox.KeyBackspace}, "backspace"},
+	}
+	for _, test := range tests {
+		got, err := parseKey(test.in)
+		if err != nil || got != test.want {
+			t.Errorf("Got : %v (%v)\nWant: %v", got, err, test.want)
+			continue
+		}
+		name := test.name
+		if name == "" {
+			name = test.in
+		}
+		if got.String() != name {
+			t.Errorf("Got : %v\nWant: %v", got.String(), name)
+		}
+	}
+
+	for _, in := range []string{"ctrl-", "hyper-a", "ctrl-ab", "alt-"} {
+		if _, err := parseKey(in); err == nil {
+			t.Errorf("Got : nil\nWant: error for '%v'", in)
+		}
+	}
+}
+
+func TestKeymapLookup(t *testing.T) {
+	km := DefaultKeymap()
+	keys := func(s string) []keyEvent {
+		ks, err := parseKeys(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ks
+	}
+	channel := []string{contextEditor, contextChannel, contextLayout, contextGlobal}
+	completion := append([]string{contextCompletion}, channel...)
+	channels := []string{contextChannels, contextGlobal}
+	sidebar := []string{contextSidebar, contextLayout, contextGlobal}
+	members := []string{contextMembers, contextLayout, contextGlobal}
+	users := []string{contextUsers, contextGlobal}
+	tests := []struct {
+		contexts []string
+		keys     string
+		action   string
+		prefix   bool
+	}{
+		{channel, "ctrl-k", "select-channel", false},
+		{channel, "up", "scroll-up", false},
+		{channels, "up", "channels-up", false},
+		{channels, "ctrl-k", "", false},
+		{channel, "ctrl-q", "quit", false},
+		{channel, "ctrl-w", "", true},
+		{channel, "ctrl-w v", "split-pane", false},
+		{channel, "ctrl-w z", "", false},
+		{channel, "a", "", false},
+		{channel, "enter", "send", false},
+		{channel, "ctrl-b", "cursor-left", false},
+		{channel, "left", "cursor-left", false},
+		{completion, "enter", "completion-accept", false},
+		{completion, "ctrl-a", "line-start", false},
+		{sidebar, "enter", "sidebar-open", false},
+		{sidebar, "ctrl-k", "", false},
+		{members, "ctrl-p", "members-profile", false},
+		{members, "a", "", false},
+		{users, "tab", "users-toggle", false},
+	}
+	for _, test := range tests {
+		a, prefix := km.lookup(test.contexts, keys(test.keys))
+		name := ""
+		if a != nil {
+			name = a.name
+		}
+		if name != test.action || prefix != test.prefix {
+			t.Errorf("%v - Got : %v, %v\nWant: %v, %v", test.keys, name, prefix, test.action, test.prefix)
+		}
+	}
+}
+
+func TestNewKeymap(t *testing.T) {
+	km, err := NewKeymap(map[string][]string{
+		"select-channel": {"ctrl-p", "ctrl-k"},
+		"split-pane":     {"ctrl-x 3"},
+		"quit":           {"ctrl-x ctrl-c"},
+		"select-team":    nil,
+	})
+	if err != nil {
+		t.Fatalf("Got : %v\nWant: nil", err)
+	}
+
+	contexts := []string{contextChannel, contextLayout, contextGlobal}
+	for keys, want := range map[string]string{
+		"ctrl-p":        "select-channel",
+		"ctrl-k":        "select-channel",
+		"ctrl-x 3":      "split-pane",
+		"ctrl-w v":      "", // Replaced
+		"ctrl-x ctrl-c": "quit",
+		"ctrl-q":        "",
+		"ctrl-t":        "", // Unbound
+		"ctrl-w c":      "close-pane",
+	} {
+		ks, _ := parseKeys(keys)
+		a, _ := km.lookup(contexts, ks)
+		if (a == nil && want != "") || (a != nil && a.name != want) {
+			t.Errorf("%v - Got : %v\nWant: %v", keys, a, want)
+		}
+	}
+
+	for _, bindings := range []map[string][]string{
+		{"nonsense": {"ctrl-p"}},
+		{"quit": {""}},
+		{"quit": {"ctrl-nope"}},
+	} {
+		if _, err := NewKeymap(bindings); err == nil {
+			t.Errorf("Got : nil\nWant: error for %v", bindings)
+		}
+	}
+
+	// Conflicts
+	for _, data := range []struct {
+		bindings map[string][]string
+		want     string
+	}{
+		{map[string][]string{"select-team": {"ctrl-k t"}}, "ctrl-k t of 'select-team' can't be pressed as ctrl-k is bound to 'select-channel'"},
+		{map[string][]string{"select-team": {"ctrl-k"}}, "ctrl-k is bound to both 'select-channel' & 'select-team'"},
+		{map[string][]string{"split-pane": {"ctrl-w"}}, "ctrl-w c of 'close-pane' can't be pressed as ctrl-w is bound to 'split-pane'"},
+	} {
+		if _, err := NewKeymap(data.bindings); err == nil || err.Error() != data.want {
+			t.Errorf("Got : %v\nWant: %v", err, data.want)
+		}
+	}
+
+	// Swapping keys isn't a conflict
+	if _, err := NewKeymap(map[string][]string{"select-team": {"ctrl-k"}, "select-channel": {"ctrl-t"}}); err != nil {
+		t.Errorf("Got : %v\nWant: nil", err)
+	}
+}
+
+func TestKeymapActive(t *testing.T) {
+	km := DefaultKeymap()
+	help := km.active([]string{contextChannels, contextGlobal})
+	if len(help) != 2 || help[0].context != contextChannels || help[1].context != contextGlobal {
+		t.Fatalf("Got : %v\nWant: channels & global", help)
+	}
+	if got := help[0].bindings[0].action.name; got != "channels-up" {
+		t.Errorf("Got : %v\nWant: channels-up", got)
+	}
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"strings"
+	"time"
+	"github.com/nsf/termbox-go"
+)
+
+// How long to wait after Esc for the rest of an Alt+key combination
+const escapeDelay = 25 * time.Millisecond
+
+// A key press, with Alt held or not
+type keyEvent struct {
+	key termbox.Key
+	ch  rune
+	alt bool
+}
+
+// Escape sequences sent by xterm compatible terminals for Alt+key, without the leading Esc
+var altSequences = map[string]termbox.Key{
+	"[1;3A": termbox.KeyArrowUp,
+	"[1;3B": termbox.KeyArrowDown,
+	"[1;3C": termbox.KeyArrowRight,
+	"[1;3D": termbox.KeyArrowLeft,
+	"[1;9C": termbox.KeyArrowRight, // iTerm2
+	"[1;9D": termbox.KeyArrowLeft,
+}
+
+// Recognises Alt+key combinations. In InputEsc mode termbox reports these as Esc followed by either the key or the
+// unrecognised remainder of an escape sequence as runes, so key presses following Esc are held back until they match
+// a combination, can't match one or escapeDelay passes.
+type keyDecoder struct {
+	pending []termbox.Event // Esc & the events after it
+	seq     int             // Incremented for each event, to detect stale flushes
+}
+
+func (kd *keyDecoder) feed(ev termbox.Event) []keyEvent {
+	kd.seq++
+	if len(kd.pending) == 0 {
+		if ev.Key == termbox.KeyEsc && ev.Ch == 0 {
+			kd.pending = append(kd.pending, ev)
+			return nil
+		}
+		return []keyEvent{{key: ev.Key, ch: ev.Ch, alt: ev.Mod&termbox.ModAlt != 0}}
+	}
+
+	// Esc then an arrow, e.g. rxvt
+	if len(kd.pending) == 1 && ev.Ch == 0 && isArrowKey(ev.Key) {
+		kd.pending = nil
+		return []keyEvent{{key: ev.Key, alt: true}}
+	}
+
+	kd.pending = append(kd.pending, ev)
+	seq, ok := kd.sequence()
+	if key, found := altSequences[seq]; ok && found {
+		kd.pending = nil
+		return []keyEvent{{key: key, alt: true}}
+	}
+	if ok && isAltPrefix(seq) {
+		return nil
+	}
+	return kd.flush()
+}
+
+// Returns true if key presses are being held back
+func (kd *keyDecoder) waiting() bool {
+	return len(kd.pending) > 0
+}
+
+// Returns the key presses held back as they were
+func (kd *keyDecoder) flush() []keyEvent {
+	keys := make([]keyEvent, len(kd.pending))
+	for i, ev := range kd.pending {
+		keys[i] = keyEvent{key: ev.Key, ch: ev.Ch}
+	}
+	kd.pending = nil
+	return keys
+}
+
+// Returns the runes following Esc, or false if a key other than a rune was pressed
+func (kd *keyDecoder) sequence() (string, bool) {
+	var sb strings.Builder
+	for _, ev := range kd.pending[1:] {
+		if ev.Ch == 0 {
+			return "", false
+		}
+		sb.WriteRune(ev.Ch)
+	}
+	return sb.String(), true
+}
+
+func isAltPrefix(s string) bool {
+	for seq := range altSequences {
+		if strings.HasPrefix(seq, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isArrowKey(key termbox.Key) bool {
+	switch key {
+	case termbox.KeyArrowUp, termbox.KeyArrowDown, termbox.KeyArrowLeft, termbox.KeyArrowRight:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+	"github.com/nsf/termbox-go"
+)
+
+func TestKeyDecoder(t *testing.T) {
+	esc := termbox.Event{Key: termbox.KeyEsc}
+	left := termbox.Event{Key: termbox.KeyArrowLeft}
+	enter := termbox.Event{Key: termbox.KeyEnter}
+	runes := func(s string) []termbox.Event {
+		var evs []termbox.Event
+		for _, r := range s {
+			evs = append(evs, termbox.Event{Ch: r})
+		}
+		return evs
+	}
+	tests := []struct {
+		evs     []termbox.Event
+		want    []keyEvent
+		waiting bool
+	}{
+		{[]termbox.Event{left}, []keyEvent{{key: termbox.KeyArrowLeft}}, false},
+		{[]termbox.Event{esc, left}, []keyEvent{{key: termbox.KeyArrowLeft, alt: true}}, false},
+		{append([]termbox.Event{esc}, runes("[1;3C")...), []keyEvent{{key: termbox.KeyArrowRight, alt: true}}, false},
+		{append([]termbox.Event{esc}, runes("[1;3")...), nil, true},
+		{append([]termbox.Event{esc}, runes("[x")...), []keyEvent{{key: termbox.KeyEsc}, {ch: '['}, {ch: 'x'}}, false},
+		{[]termbox.Event{esc, enter}, []keyEvent{{key: termbox.KeyEsc}, {key: termbox.KeyEnter}}, false},
+		{[]termbox.Event{esc}, nil, true},
+	}
+	for _, test := range tests {
+		var kd keyDecoder
+		var got []keyEvent
+		for _, ev := range test.evs {
+			got = append(got, kd.feed(ev)...)
+		}
+		if !reflect.DeepEqual(got, test.want) || kd.waiting() != test.waiting {
+			t.Errorf("Got : %v (waiting %v)\nWant: %v (waiting %v)", got, kd.waiting(), test.want, test.waiting)
+		}
+	}
+}
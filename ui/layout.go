@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+const (
+	minSidebarWidth = 16
+	maxSidebarRatio = 2 // Sidebar can't be wider than 1/2 of the screen
+
+	minPaneWidth  = 30
+	minPaneHeight = 8
+)
+
+// Splits the screen into the channel sidebar & one or more message panes, with the status bar underneath. Panes are
+// side by side or stacked & each shows its own channel, only the focused one receives input.
+type SplitView struct {
+	ctrl    Controller
+	status  *Status
+	sidebar *Sidebar
+	panes   []*ChannelView
+	focus   int  // Index of the focused pane
+	stacked bool // Panes are arranged top to bottom
+	width   int  // Of the sidebar
+
+	timeFormat string // Of messages in every pane
+}
+
+func NewSplitView(ctrl Controller, status *Status, sidebar *Sidebar, width int, timeFormat string) *SplitView {
+	if width <= 0 {
+		width = sidebarWidth
+	}
+	return &SplitView{ ctrl: ctrl, status: status, sidebar: sidebar, width: width, timeFormat: timeFormat }
+}
+
+// Passes keys not bound to actions to the focused pane, the sidebar has none
+func (sv *SplitView) OnKey(key termbox.Key, r rune) {
+	if !sv.sidebar.focused && len(sv.panes) > 0 {
+		sv.main().OnKey(key, r)
+	}
+}
+
+// The focused pane
+func (sv *SplitView) main() *ChannelView {
+	return sv.panes[sv.focus]
+}
+
+// Shows the channel in the focused pane, creating it if there are none. The pane is kept if it already shows the
+// channel, otherwise its draft is saved first so the new view can restore it.
+func (sv *SplitView) show(cl *Channel) *ChannelView {
+	if len(sv.panes) > 0 {
+		if sv.main().cl == cl {
+			return sv.main()
+		}
+		sv.main().saveDraft()
+	}
+	cv := NewChannelView(sv.ctrl, cl, sv.status, sv.timeFormat)
+	if len(sv.panes) == 0 {
+		sv.panes = append(sv.panes, cv)
+	}
+	sv.panes[sv.focus] = cv
+	return cv
+}
+
+// Refilters the open member panels after members are loaded or change presence
+func (sv *SplitView) matchMembers() {
+	for _, cv := range sv.panes {
+		if cv.members != nil {
+			cv.members.match()
+		}
+	}
+}
+
+// Returns true if a pane shows the channel
+func (sv *SplitView) shows(cl *Channel) bool {
+	for _, cv := range sv.panes {
+		if cv.cl == cl {
+			return true
+		}
+	}
+	return false
+}
+
+// Opens the current channel in a new pane after the focused one
+func (sv *SplitView) split() {
+	w, h := termbox.Size()
+	if n := len(sv.panes) + 1; (sv.stacked && (h-1)/n < minPaneHeight) || (!sv.stacked && (w-sv.width)/n < minPaneWidth) {
+		sv.status.msg, sv.status.info = "Not enough room for another pane", false
+		sv.ctrl.Redraw()
+		return
+	}
+	cv := NewChannelView(sv.ctrl, sv.main().cl, sv.status, sv.timeFormat)
+	sv.panes = append(sv.panes[:sv.focus+1], append([]*ChannelView{cv}, sv.panes[sv.focus+1:]...)...)
+	sv.focusPane(sv.focus + 1)
+}
+
+// Closes the focused pane, unless it is the last
+func (sv *SplitView) close() {
+	if len(sv.panes) == 1 {
+		return
+	}
+	sv.main().saveDraft()
+	sv.panes = append(sv.panes[:sv.focus], sv.panes[sv.focus+1:]...)
+	if sv.focus == len(sv.panes) {
+		sv.focus--
+	}
+	sv.focusPane(sv.focus)
+}
+
+// Removes every pane, e.g. after leaving the last channel
+func (sv *SplitView) clear() {
+	sv.panes = nil
+	sv.focus = 0
+}
+
+// Closes the unfocused panes showing the channel, e.g. after leaving it
+func (sv *SplitView) closeChannel(cl *Channel) {
+	focused := sv.main()
+	panes := sv.panes[:0]
+	for _, cv := range sv.panes {
+		if cv.cl != cl || cv == focused {
+			if cv == focused {
+				sv.focus = len(panes)
+			}
+			panes = append(panes, cv)
+		}
+	}
+	sv.panes = panes
+}
+
+func (sv *SplitView) focusPane(i int) {
+	sv.focus = i
+	sv.ctrl.FocusPane(sv.main())
+	sv.ctrl.Redraw()
+}
+
+// Moves focus between the sidebar & messages
+func (sv *SplitView) focusSidebar(focus bool) {
+	sv.sidebar.focused = focus
+	if focus {
+		sv.sidebar.refresh()
+		sv.sidebar.selectChannel(sv.main().cl)
+	}
+	sv.ctrl.Redraw()
+}
+
+// Widens (positive) or narrows the sidebar from its width on screen
+func (sv *SplitView) resize(inc int) {
+	w, _ := termbox.Size()
+	sv.width = sv.sidebarWidth(w) + inc
+	sv.ctrl.Redraw()
+}
+
+// Returns the width of the sidebar kept within its limits for the terminal width. The chosen width is kept for when
+// the terminal is wide enough again.
+func (sv *SplitView) sidebarWidth(w int) int {
+	width := sv.width
+	if width > w/maxSidebarRatio {
+		width = w / maxSidebarRatio
+	}
+	if width < minSidebarWidth {
+		width = minSidebarWidth
+	}
+	return width
+}
+
+// Divides lo <= i < hi into n parts, returning their n+1 boundaries. Earlier parts take any remainder.
+func splitRange(lo, hi, n int) []int {
+	if n <= 0 {
+		return []int{lo}
+	}
+	bounds := make([]int, n+1)
+	size, extra := (hi-lo)/n, (hi-lo)%n
+	bounds[0] = lo
+	for i := 1; i <= n; i++ {
+		bounds[i] = bounds[i-1] + size
+		if i <= extra {
+			bounds[i]++
+		}
+	}
+	return bounds
+}
+
+// Returns the region of each pane as x0, y0, x1, y1 within x0 <= x < x1, 0 <= y < h
+func (sv *SplitView) paneRects(x0, x1, h int) [][4]int {
+	rects := make([][4]int, len(sv.panes))
+	if sv.stacked {
+		ys := splitRange(0, h, len(sv.panes))
+		for i := range rects {
+			rects[i] = [4]int{x0, ys[i], x1, ys[i+1]}
+		}
+	} else {
+		xs := splitRange(x0, x1, len(sv.panes))
+		for i := range rects {
+			rects[i] = [4]int{xs[i], 0, xs[i+1], h}
+		}
+	}
+	return rects
+}
+
+func (sv *SplitView) Draw(term Terminal) {
+	term.Clear(coldef, coldef)
+	term.HideCursor()
+
+	w, h := term.Size()
+	x := sv.sidebarWidth(w)
+	sv.sidebar.Draw(0, x, h-1, term)
+
+	// Focused pane last so its completions are drawn over the others
+	rects := sv.paneRects(x, w, h-1)
+	for i, cv := range sv.panes {
+		if i != sv.focus {
+			r := rects[i]
+			cv.DrawIn(r[0], r[1], r[2], r[3], false, term)
+		}
+	}
+	if len(sv.panes) > 0 {
+		r := rects[sv.focus]
+		sv.main().DrawIn(r[0], r[1], r[2], r[3], !sv.sidebar.focused, term)
+	}
+
+	// Draw status bar
+	if sv.status.msg != "" {
+		fg := termbox.ColorRed
+		if sv.status.info {
+			fg = coldef
+		}
+		printString(sv.status.msg, 1, h-1, fg, coldef, term)
+	}
+	x = printPresence(sv.status.team.presence, w-1, h-1, term)
+	printTeamBadges(sv.status.team, sv.status.teams, x-1, h-1, term)
+
+	term.Flush()
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+	"github.com/nsf/termbox-go"
+)
+
+func TestSidebarWidth(t *testing.T) {
+	tests := []struct {
+		width, termWidth, want int
+	}{
+		{0, 120, sidebarWidth},
+		{28, 120, 28},
+		{80, 120, 60},
+		{28, 40, 20},
+		{28, 20, minSidebarWidth},
+		{4, 120, minSidebarWidth},
+	}
+	for _, test := range tests {
+		sv := NewSplitView(nil, nil, nil, test.width, "")
+		if got := sv.sidebarWidth(test.termWidth); got != test.want {
+			t.Errorf("Got : %v\nWant: %v", got, test.want)
+		}
+	}
+
+	// A narrow terminal doesn't change the chosen width
+	sv := NewSplitView(nil, nil, nil, 28, "")
+	sv.sidebarWidth(40)
+	if got := sv.sidebarWidth(120); got != 28 {
+		t.Errorf("Got : %v\nWant: 28", got)
+	}
+}
+
+func TestPaneRects(t *testing.T) {
+	tests := []struct {
+		panes   int
+		stacked bool
+		want    [][4]int
+	}{
+		{0, false, [][4]int{}},
+		{1, false, [][4]int{{28, 0, 120, 40}}},
+		{2, false, [][4]int{{28, 0, 74, 40}, {74, 0, 120, 40}}},
+		{3, false, [][4]int{{28, 0, 59, 40}, {59, 0, 90, 40}, {90, 0, 120, 40}}},
+		{2, true, [][4]int{{28, 0, 120, 20}, {28, 20, 120, 40}}},
+		{3, true, [][4]int{{28, 0, 120, 14}, {28, 14, 120, 27}, {28, 27, 120, 40}}},
+	}
+	for _, test := range tests {
+		sv := &SplitView{panes: make([]*ChannelView, test.panes), stacked: test.stacked}
+		if got := sv.paneRects(28, 120, 40); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Got : %v\nWant: %v", got, test.want)
+		}
+	}
+}
+
+func TestCloseChannelPanes(t *testing.T) {
+	a, b := &Channel{id: "C1"}, &Channel{id: "C2"}
+	panes := []*ChannelView{{cl: a}, {cl: b}, {cl: a}, {cl: b}}
+	sv := &SplitView{panes: append([]*ChannelView{}, panes...), focus: 2}
+	sv.closeChannel(a)
+
+	want := []*ChannelView{panes[1], panes[2], panes[3]}
+	if !reflect.DeepEqual(sv.panes, want) || sv.main() != panes[2] {
+		t.Errorf("Got : %v (focus %v)\nWant: %v (focus 1)", sv.panes, sv.focus, want)
+	}
+}
+
+func TestClipTerminal(t *testing.T) {
+	term := &cellTerminal{cells: make(map[int]termbox.Cell)}
+	clip := &clipTerminal{Terminal: term, y0: 5, h: 2}
+	for y := -1; y < 3; y++ {
+		clip.SetCell('a' + rune(y+1), 0, y, coldef, coldef)
+	}
+
+	got := []string{term.line(4, 1), term.line(5, 1), term.line(6, 1), term.line(7, 1)}
+	want := []string{"", "b", "c", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got : %q\nWant: %q", got, want)
+	}
+}
+
+func TestShowKeepsDraft(t *testing.T) {
+	a, b := &Channel{id: "C1", draft: "old"}, &Channel{id: "C2"}
+	sv := &SplitView{}
+	cv := sv.show(a)
+	cv.editor.SetText("typed")
+
+	// Showing the same channel keeps the pane & what was typed
+	if got := sv.show(a); got != cv || got.editor.GetText() != "typed" {
+		t.Errorf("Got : %q\nWant: %q", got.editor.GetText(), "typed")
+	}
+
+	// Leaving & coming back restores it
+	sv.show(b)
+	if got := sv.show(a).editor.GetText(); got != "typed" {
+		t.Errorf("Got : %q\nWant: %q", got, "typed")
+	}
+}
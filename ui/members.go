@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"github.com/nsf/termbox-go"
+	"github.com/mattn/go-runewidth"
+	"github.com/g-dx/rosslyn/slack"
+)
+
+const memberPanelWidth = 32
+
+// Side panel listing the members of a channel
+type MemberPanel struct {
+	ctrl    Controller
+	cl      *Channel
+	users   *slack.UserList
+	filter  []rune
+	matches []string
+	pos     int
+	offset  int // First visible match
+	focused bool
+	card    bool // Display profile of current member
+}
+
+func NewMemberPanel(ctrl Controller, cl *Channel, users *slack.UserList) *MemberPanel {
+	mp := &MemberPanel{ ctrl: ctrl, cl: cl, users: users, focused: true }
+	if cl.memberIDs == nil {
+		ctrl.LoadMembers(cl)
+	}
+	mp.match()
+	return mp
+}
+
+// Edits the filter, other keys are bound to actions
+func (mp *MemberPanel) OnKey(key termbox.Key, r rune) {
+	switch key {
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		if len(mp.filter) > 0 {
+			mp.filter = mp.filter[:len(mp.filter)-1]
+			mp.pos, mp.offset = 0, 0
+			mp.match()
+		}
+	case termbox.KeySpace:
+		r = ' '
+		fallthrough
+	default:
+		if r != 0 {
+			mp.filter = append(mp.filter, r)
+			mp.pos, mp.offset = 0, 0
+			mp.match()
+		}
+	}
+	mp.ctrl.Redraw()
+}
+
+// Moves the selection up (negative) or down
+func (mp *MemberPanel) move(inc int) {
+	if pos := mp.pos + inc; pos >= 0 && pos < len(mp.matches) {
+		mp.pos = pos
+	}
+	mp.ctrl.Redraw()
+}
+
+// Opens a conversation with the selected member
+func (mp *MemberPanel) open() {
+	if id := mp.current(); id != "" {
+		mp.ctrl.OpenConversation([]string{id})
+	}
+}
+
+// Shows or hides the profile of the selected member
+func (mp *MemberPanel) toggleCard() {
+	mp.card = !mp.card && mp.current() != ""
+	mp.ctrl.Redraw()
+}
+
+// Hides the profile, otherwise returns focus to the messages
+func (mp *MemberPanel) close() {
+	if mp.card {
+		mp.card = false
+	} else {
+		mp.focused = false
+	}
+	mp.ctrl.Redraw()
+}
+
+func (mp *MemberPanel) current() string {
+	if mp.pos < len(mp.matches) {
+		return mp.matches[mp.pos]
+	}
+	return ""
+}
+
+// Filters members by name & real name, ordering active members first. Called when the filter, the members or their
+// presence change.
+func (mp *MemberPanel) match() {
+	users := mp.users
+	filter := string(mp.filter)
+	mp.matches = mp.matches[:0]
+	for _, id := range mp.cl.memberIDs {
+		if _, ok := fuzzyBest(filter, users.GetName(id), users.GetRealName(id)); ok {
+			mp.matches = append(mp.matches, id)
+		}
+	}
+	sort.SliceStable(mp.matches, func(i, j int) bool {
+		a, b := mp.matches[i], mp.matches[j]
+		activeA, activeB := users.GetPresence(a) == "active", users.GetPresence(b) == "active"
+		if activeA != activeB {
+			return activeA
+		}
+		return users.GetName(a) < users.GetName(b)
+	})
+	if mp.pos >= len(mp.matches) {
+		mp.pos = 0
+	}
+}
+
+// Draws the panel in the region x0 <= x < x1, 0 <= y < h
+func (mp *MemberPanel) Draw(x0, x1, h int, term Terminal) {
+	borderFg := lineColour
+	if mp.focused {
+		borderFg = termbox.ColorWhite
+	}
+	printBorder(x0, 0, x1, h, term)
+	printString(fmt.Sprintf(" Members (%v) ", len(mp.cl.memberIDs)), x0+2, 0, borderFg, coldef, term)
+
+	x, w := x0+1, x1-x0-2
+	if mp.card {
+		mp.drawCard(mp.current(), x, w, term)
+		return
+	}
+
+	// Filter
+	printString("/ " + string(mp.filter), x, 1, termbox.ColorWhite, coldef, term)
+
+	// Members
+	users := mp.users
+	if mp.cl.memberIDs == nil {
+		printString("Loading...", x, 2, coldef, coldef, term)
+		return
+	}
+	mp.offset = scrollWindow(mp.pos, mp.offset, h-3)
+	y := 2
+	for i := mp.offset; i < len(mp.matches) && y < h-1; i++ {
+		id := mp.matches[i]
+		fg, bg := coldef, coldef
+		if mp.focused && mp.pos == i {
+			fg, bg = termbox.ColorWhite, termbox.ColorYellow
+		}
+		pos := printUserPresence(users.GetPresence(id), x, y, term)
+
+		profile := users.GetProfile(id)
+		name := users.GetName(id)
+		if profile.StatusEmoji != "" {
+			name += " " + emojize(profile.StatusEmoji)
+		}
+		printString(runewidth.Truncate(name, w-2, "…"), pos+1, y, fg, bg, term)
+		y++
+	}
+}
+
+// Draws the profile of a user
+func (mp *MemberPanel) drawCard(id string, x, w int, term Terminal) {
+	users := mp.users
+	profile := users.GetProfile(id)
+
+	lines := []string{
+		users.GetRealName(id),
+		"@" + users.GetName(id),
+		profile.Title,
+		"",
+		users.GetPresence(id),
+		emojize(profile.StatusEmoji) + " " + profile.StatusText,
+		profile.Email,
+	}
+	if offset, ok := users.GetTzOffset(id); ok {
+		local := time.Now().UTC().Add(time.Duration(offset) * time.Second)
+		lines = append(lines, fmt.Sprintf("%v (%v)", local.Format("3:04 PM"), users.GetTzLabel(id)))
+	}
+	lines = append(lines, "", "Enter: message, Esc: back")
+
+	for i, line := range lines {
+		fg := coldef
+		if i == 0 {
+			fg = termbox.ColorWhite | termbox.AttrBold
+		}
+		printString(runewidth.Truncate(line, w, "…"), x, 1+i, fg, coldef, term)
+	}
+}
+
+// Prints an indicator for a user's presence & returns the next position
+func printUserPresence(presence string, x, y int, term Terminal) int {
+	switch presence {
+	case "active":
+		return printString("●", x, y, termbox.Attribute(30), coldef, term)
+	case "away":
+		return printString("○", x, y, termbox.ColorWhite, coldef, term)
+	default:
+		return printString("?", x, y, termbox.ColorRed, coldef, term)
+	}
+}
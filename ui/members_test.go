@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"github.com/nsf/termbox-go"
+	"github.com/g-dx/rosslyn/slack"
+)
+
+func testUsers(t *testing.T) *slack.UserList {
+	users := &slack.UserList{}
+	err := json.Unmarshal([]byte(`{"members": [
+		{"id": "U1", "name": "alice", "real_name": "Alice Smith", "presence": "active", "tz": "Asia/Kolkata", "tz_offset": 19800},
+		{"id": "U2", "name": "bob", "real_name": "Bob Jones", "presence": "away"},
+		{"id": "U3", "name": "carol", "real_name": "Carol Smith", "presence": "active"},
+		{"id": "U4", "name": "dave", "real_name": "Dave Brown", "presence": "active"}
+	]}`), users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
+
+func TestMemberPanelMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"U1", "U3", "U2"}},
+		{"bob", []string{"U2"}},
+		{"smith", []string{"U1", "U3"}},
+		{"dave", []string{}},
+	}
+	cl := &Channel{memberIDs: []string{"U2", "U3", "U1"}}
+	for _, test := range tests {
+		mp := NewMemberPanel(nil, cl, testUsers(t))
+		mp.filter = []rune(test.filter)
+		mp.match()
+		if !reflect.DeepEqual(mp.matches, test.want) {
+			t.Errorf("Filter %q\nGot : %v\nWant: %v", test.filter, mp.matches, test.want)
+		}
+	}
+}
+
+func TestMemberPanelPresence(t *testing.T) {
+	users := testUsers(t)
+	mp := NewMemberPanel(nil, &Channel{memberIDs: []string{"U1", "U2"}}, users)
+	term := &cellTerminal{cells: make(map[int]termbox.Cell)}
+	mp.Draw(0, 20, 10, term)
+	for y, want := range []string{"│● alice", "│○ bob"} {
+		if got := term.line(y+2, 20); got[:len(want)] != want {
+			t.Errorf("Got : %q\nWant: %q", got, want)
+		}
+	}
+
+	// A presence change reorders the members once they are rematched
+	users.SetPresence("U1", "away")
+	users.SetPresence("U2", "active")
+	mp.match()
+	term = &cellTerminal{cells: make(map[int]termbox.Cell)}
+	mp.Draw(0, 20, 10, term)
+	for y, want := range []string{"│● bob", "│○ alice"} {
+		if got := term.line(y+2, 20); got[:len(want)] != want {
+			t.Errorf("Got : %q\nWant: %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Detects messages which mention us directly, notify the whole channel or contain one of our keywords
+type mentionMatcher struct {
+	keywords *regexp.Regexp // Nil if no keywords are configured
+}
+
+func newMentionMatcher(keywords []string) *mentionMatcher {
+	quoted := make([]string, 0, len(keywords))
+	for _, kw := range keywords {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			quoted = append(quoted, regexp.QuoteMeta(kw))
+		}
+	}
+	if len(quoted) == 0 {
+		return &mentionMatcher{}
+	}
+	// Keywords must be whole words but may contain non-word characters themselves (e.g. "c++")
+	return &mentionMatcher{keywords: regexp.MustCompile(`(?i)(?:^|\W)(?:` + strings.Join(quoted, "|") + `)(?:\W|$)`)}
+}
+
+// Checks the raw Slack text for mentions of self & the formatted content for keywords
+func (mm *mentionMatcher) matches(self, raw, content string) bool {
+	switch {
+	case self != "" && (strings.Contains(raw, "<@"+self+">") || strings.Contains(raw, "<@"+self+"|")):
+		return true
+	case strings.Contains(raw, "<!here"), strings.Contains(raw, "<!channel"), strings.Contains(raw, "<!everyone"):
+		return true
+	case mm.keywords != nil:
+		return mm.keywords.MatchString(content)
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestMentionMatcher(t *testing.T) {
+	mm := newMentionMatcher([]string{"deploy", " ", "c++"})
+	tests := []struct {
+		raw, content string
+		want         bool
+	}{
+		{"hello", "hello", false},
+		{"<@U123> hello", "@me hello", true},
+		{"<@U123|me> hello", "me hello", true},
+		{"<@U1234> hello", "@other hello", false},
+		{"<!here> lunch?", "@here lunch?", true},
+		{"<!here|@here> lunch?", "@here lunch?", true},
+		{"<!channel> lunch?", "@channel lunch?", true},
+		{"<!everyone> lunch?", "@everyone lunch?", true},
+		{"Starting the DEPLOY now", "Starting the DEPLOY now", true},
+		{"redeployed", "redeployed", false},
+		{"who knows c++?", "who knows c++?", true},
+	}
+
+	for _, data := range tests {
+		got := mm.matches("U123", data.raw, data.content)
+		if got != data.want {
+			t.Errorf("matches(%q)\nGot : '%v'\nWant: '%v'", data.raw, got, data.want)
+		}
+	}
+
+	if newMentionMatcher(nil).matches("U123", "deploy", "deploy") {
+		t.Errorf("Got 'true', Wanted: 'false'")
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"time"
 	"strconv"
 	"strings"
@@ -9,18 +10,29 @@ import (
 
 
 type Message struct {
-	User     string
-	Text     string
-	Ts       string
-	T        time.Time
-	Formats  []format
-	IsEdited bool
+	User      string
+	Text      string
+	Ts        string
+	T         time.Time
+	Formats   []format
+	IsEdited  bool
+	IsMention bool
+	IsAction  bool // Sent using "/me"
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Information displayed in the status line
+type Status struct {
+	team  *team   // Current team
+	teams []*team // All teams, for unread badges
+	msg   string  // Error/information message, if any
+	info  bool    // Message is information rather than an error
 }
 
 // ---------------------------------------------------------------------------------------------------------------------
 
 type ChannelList struct {
-	// TODO: Split into groups, channels, ims, mpims?
 	chls []*Channel
 }
 
@@ -32,6 +44,12 @@ func (cs *ChannelList) add(cl *Channel) {
 	})
 }
 
+func (cs *ChannelList) remove(id string) {
+	if i, _ := cs.find(id); i != -1 {
+		cs.chls = append(cs.chls[:i], cs.chls[i+1:]...)
+	}
+}
+
 func (cs *ChannelList) find(id string) (int, *Channel) {
 	i := sort.Search(len(cs.chls), func(i int) bool { return cs.chls[i].id >= id })
 	if i < len(cs.chls) && cs.chls[i].id == id {
@@ -40,6 +58,17 @@ func (cs *ChannelList) find(id string) (int, *Channel) {
 	return -1, nil
 }
 
+// Finds a channel (not a DM) by name, with or without a leading '#'
+func (cs *ChannelList) findByName(name string) *Channel {
+	name = strings.TrimPrefix(name, "#")
+	for _, cl := range cs.chls {
+		if cl.user == "" && cl.name == name && name != "" {
+			return cl
+		}
+	}
+	return nil
+}
+
 func (cl *ChannelList) Size() int {
 	return len(cl.chls)
 }
@@ -52,7 +81,24 @@ type Channel struct {
 	msgs []*Message
 	pos int
 	unread int
+	mentions int // Unread messages which mention us
+	lastRead string // Timestamp of the last message read
 	user string // IM channels only...
+	mpim bool
+	topic, purpose string
+	members int
+	memberIDs []string // Only loaded on demand
+	team *team
+	viewed time.Time // When last switched to
+	typing *UserTypingTimer // Nil until someone types
+	draft string // Unsent text
+}
+
+func (cl *Channel) usersTyping() []string {
+	if cl.typing == nil {
+		return nil
+	}
+	return cl.typing.UsersTyping()
 }
 
 func (cl *Channel) AddSent(msg *Message) {
@@ -82,6 +128,90 @@ func (cl *Channel) findByTs(ts string) *Message {
 	return nil
 }
 
+// Returns the time of the last activity we know of: the latest of the last message, the last read message & when we
+// last viewed the channel
+func (cl *Channel) recency() time.Time {
+	t := cl.viewed
+	if len(cl.msgs) > 0 && cl.msgs[len(cl.msgs)-1].T.After(t) {
+		t = cl.msgs[len(cl.msgs)-1].T
+	}
+	if sec, _ := splitTs(cl.lastRead); sec != "" {
+		if n, err := strconv.ParseInt(sec, 10, 64); err == nil && time.Unix(n, 0).After(t) {
+			t = time.Unix(n, 0)
+		}
+	}
+	return t
+}
+
+// Sets the read state loaded from Slack, unless we have since read further, e.g. a slow reply after marking it read
+func (cl *Channel) setReadState(lastRead string, unread, mentions int) {
+	if tsAfter(cl.lastRead, lastRead) {
+		return
+	}
+	cl.lastRead = lastRead
+	cl.unread = unread
+	cl.mentions = mentions
+}
+
+// Returns true if the message at the end of the channel is unread
+func (cl *Channel) hasUnread() bool {
+	return len(cl.msgs) > 0 && tsAfter(cl.msgs[len(cl.msgs)-1].Ts, cl.lastRead)
+}
+
+// Compares two Slack timestamps ("<seconds>.<micros>"). An empty timestamp is before all others.
+func tsAfter(a, b string) bool {
+	aSecs, aFrac := splitTs(a)
+	bSecs, bFrac := splitTs(b)
+	if len(aSecs) != len(bSecs) {
+		return len(aSecs) > len(bSecs)
+	}
+	if aSecs != bSecs {
+		return aSecs > bSecs
+	}
+	// Pad fractions to compare digit by digit
+	for len(aFrac) < len(bFrac) {
+		aFrac += "0"
+	}
+	for len(bFrac) < len(aFrac) {
+		bFrac += "0"
+	}
+	return aFrac > bFrac
+}
+
+// Returns the timestamp one microsecond before ts
+func tsBefore(ts string) string {
+	secs, frac := splitTs(ts)
+	n, _ := strconv.ParseInt(secs, 10, 64)
+	f, _ := strconv.ParseInt(frac, 10, 64)
+	if f == 0 {
+		n--
+		f = 1
+		for range frac {
+			f *= 10
+		}
+	}
+	return fmt.Sprintf("%v.%0*d", n, len(frac), f-1)
+}
+
+// Returns the number of messages & mentions after ts
+func (cl *Channel) countAfter(ts string) (int, int) {
+	n, mentions := 0, 0
+	for i := len(cl.msgs)-1; i >= 0 && tsAfter(cl.msgs[i].Ts, ts); i-- {
+		n++
+		if cl.msgs[i].IsMention {
+			mentions++
+		}
+	}
+	return n, mentions
+}
+
+func splitTs(ts string) (string, string) {
+	if i := strings.Index(ts, "."); i != -1 {
+		return strings.TrimLeft(ts[:i], "0"), ts[i+1:]
+	}
+	return strings.TrimLeft(ts, "0"), ""
+}
+
 func fromTsToTime(ts string) time.Time {
 	i, err := strconv.ParseInt(ts[:strings.Index(ts, ".")], 10, 64)
 	if err != nil {
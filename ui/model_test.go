@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTsAfter(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1503435956.000247", "1503435956.000246", true},
+		{"1503435956.000246", "1503435956.000247", false},
+		{"1503435956.000247", "1503435956.000247", false},
+		{"1503435957.000000", "1503435956.999999", true},
+		{"1503435956.00001", "1503435956.000009", true}, // Short fraction
+		{"1503435956.00000", "1503435956.000000", false},
+		{"1503435956.000001", "", true},
+		{"", "1503435956.000001", false},
+		{"999999999.000000", "1000000000.000000", false}, // Fewer digits
+	}
+
+	for _, data := range tests {
+		got := tsAfter(data.a, data.b)
+		if got != data.want {
+			t.Errorf("tsAfter(%q, %q)\nGot : '%v'\nWant: '%v'", data.a, data.b, got, data.want)
+		}
+	}
+}
+
+func TestTsBefore(t *testing.T) {
+	tests := []struct {
+		ts, want string
+	}{
+		{"1503435956.000247", "1503435956.000246"},
+		{"1503435956.000010", "1503435956.000009"},
+		{"1503435956.000000", "1503435955.999999"},
+		{"1503435956.00000", "1503435955.99999"},
+	}
+
+	for _, data := range tests {
+		got := tsBefore(data.ts)
+		if got != data.want {
+			t.Errorf("tsBefore(%q)\nGot : '%v'\nWant: '%v'", data.ts, got, data.want)
+		}
+	}
+}
+
+func TestSetReadState(t *testing.T) {
+	tests := []struct {
+		lastRead             string
+		unread, mentions     int
+		wantRead             string
+		wantUnread, wantMent int
+	}{
+		{"1503435956.000247", 0, 0, "1503435956.000247", 0, 0},
+		{"1503435956.000300", 2, 1, "1503435956.000300", 2, 1},
+		{"1503435956.000100", 5, 2, "1503435956.000247", 1, 1},
+	}
+
+	for _, data := range tests {
+		cl := &Channel{lastRead: "1503435956.000247", unread: 1, mentions: 1}
+		cl.setReadState(data.lastRead, data.unread, data.mentions)
+		if cl.lastRead != data.wantRead || cl.unread != data.wantUnread || cl.mentions != data.wantMent {
+			t.Errorf("setReadState(%q, %v, %v)\nGot : %q, %v, %v\nWant: %q, %v, %v", data.lastRead, data.unread,
+				data.mentions, cl.lastRead, cl.unread, cl.mentions, data.wantRead, data.wantUnread, data.wantMent)
+		}
+	}
+}
+
+func TestChannelListRemove(t *testing.T) {
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"C2", []string{"C1", "C3"}},
+		{"C1", []string{"C2", "C3"}},
+		{"C3", []string{"C1", "C2"}},
+		{"C4", []string{"C1", "C2", "C3"}},
+	}
+	for _, test := range tests {
+		cs := &ChannelList{}
+		for _, id := range []string{"C3", "C1", "C2"} {
+			cs.add(&Channel{id: id})
+		}
+		cs.remove(test.id)
+
+		var got []string
+		for _, cl := range cs.chls {
+			got = append(got, cl.id)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("remove(%q)\nGot : %v\nWant: %v", test.id, got, test.want)
+		}
+		if _, cl := cs.find(test.id); cl != nil {
+			t.Errorf("find(%q) after remove\nGot : %v\nWant: nil", test.id, cl.id)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"github.com/0xAX/notificator"
+)
+
+// Delivers notifications to the user
+type Notifier interface {
+	Notify(title, body string) error
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type desktopNotifier struct {
+	n *notificator.Notificator
+}
+
+// Creates a Notifier which uses libnotify (or equivalent) desktop notifications
+func NewDesktopNotifier() Notifier {
+	return &desktopNotifier{notificator.New(notificator.Options{AppName: "Rosslyn"})}
+}
+
+func (dn *desktopNotifier) Notify(title, body string) error {
+	return dn.n.Push(title, body, "", notificator.UR_NORMAL)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type NotifyLevel string
+
+const (
+	NotifyAll      NotifyLevel = "all"
+	NotifyMentions NotifyLevel = "mentions"
+	NotifyMuted    NotifyLevel = "muted"
+)
+
+func ParseNotifyLevel(s string) (NotifyLevel, error) {
+	switch l := NotifyLevel(s); l {
+	case NotifyAll, NotifyMentions, NotifyMuted:
+		return l, nil
+	}
+	return "", fmt.Errorf("unknown notification level '%v' (want all, mentions or muted)", s)
+}
+
+// Rules deciding which messages produce notifications
+type NotifyConfig struct {
+	Level    NotifyLevel            // Default for channels not listed below
+	Channels map[string]NotifyLevel // Channel or DM user name, optionally prefixed by "team/" (without '#' or '@') -> level
+	Keywords []string               // Always notify for these, unless muted
+	Quiet    QuietHours             // No notifications during these hours
+	Throttle time.Duration          // Minimum time between notifications for a channel
+}
+
+// Period of the day, in minutes since midnight, which may wrap past midnight
+type QuietHours struct {
+	Start, End int
+}
+
+// Parses "HH:MM-HH:MM", an empty string means no quiet hours
+func ParseQuietHours(s string) (QuietHours, error) {
+	if s == "" {
+		return QuietHours{}, nil
+	}
+	var sh, sm, eh, em int
+	if n, _ := fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em); n != 4 || sh > 23 || eh > 23 || sm > 59 || em > 59 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours '%v' (want HH:MM-HH:MM)", s)
+	}
+	return QuietHours{sh*60 + sm, eh*60 + em}, nil
+}
+
+func (qh QuietHours) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	switch {
+	case qh.Start == qh.End:
+		return false // Not set
+	case qh.Start < qh.End:
+		return m >= qh.Start && m < qh.End
+	default:
+		return m >= qh.Start || m < qh.End // Wraps midnight
+	}
+}
+
+type notifyRules struct {
+	cfg      NotifyConfig
+	keywords *mentionMatcher
+	last     map[*Channel]time.Time // Last notification per channel
+	lastTs   map[*Channel]string    // & the message it was for
+}
+
+func newNotifyRules(cfg NotifyConfig) *notifyRules {
+	if cfg.Level == "" {
+		cfg.Level = NotifyMentions
+	}
+	return &notifyRules{
+		cfg:      cfg,
+		keywords: newMentionMatcher(cfg.Keywords),
+		last:     make(map[*Channel]time.Time),
+		lastTs:   make(map[*Channel]string),
+	}
+}
+
+// Decides whether a message in the channel should produce a notification. IMs & MPIMs are always treated as mentions.
+// Slack can report a message twice, as a message & a desktop notification, so only the first is allowed.
+func (nr *notifyRules) allow(cl *Channel, ts string, mention bool, content string, now time.Time) bool {
+	level := nr.level(cl)
+	switch {
+	case level == NotifyMuted:
+		return false
+	case nr.cfg.Quiet.contains(now):
+		return false
+	case level == NotifyMentions && !mention && cl.user == "" && !cl.mpim && !nr.keywords.matches("", "", content):
+		return false
+	case ts != "" && nr.lastTs[cl] == ts:
+		return false // Already notified
+	case now.Sub(nr.last[cl]) < nr.cfg.Throttle:
+		return false
+	}
+	nr.last[cl] = now
+	nr.lastTs[cl] = ts
+	return true
+}
+
+// Returns the level for the channel, preferring one given for its team. DMs are named after the other user.
+func (nr *notifyRules) level(cl *Channel) NotifyLevel {
+	name := strings.TrimPrefix(cl.name, "#")
+	if cl.team != nil {
+		if cl.user != "" {
+			name = cl.team.apis.GetUserList().GetName(cl.user)
+		}
+		if level, ok := nr.cfg.Channels[cl.team.name+"/"+name]; ok {
+			return level
+		}
+	}
+	if level, ok := nr.cfg.Channels[name]; ok {
+		return level
+	}
+	return nr.cfg.Level
+}
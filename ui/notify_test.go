@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		s    string
+		want QuietHours
+		err  bool
+	}{
+		{"", QuietHours{}, false},
+		{"22:00-07:30", QuietHours{22 * 60, 7*60 + 30}, false},
+		{"09:15-17:00", QuietHours{9*60 + 15, 17 * 60}, false},
+		{"22:00", QuietHours{}, true},
+		{"24:00-07:00", QuietHours{}, true},
+		{"22:60-07:00", QuietHours{}, true},
+		{"late", QuietHours{}, true},
+	}
+
+	for _, data := range tests {
+		got, err := ParseQuietHours(data.s)
+		if (err != nil) != data.err || got != data.want {
+			t.Errorf("ParseQuietHours(%q)\nGot : '%v', '%v'\nWant: '%v', error: %v", data.s, got, err, data.want, data.err)
+		}
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2017, 1, 1, h, m, 0, 0, time.Local) }
+	overnight := QuietHours{22 * 60, 7 * 60}
+	daytime := QuietHours{9 * 60, 17 * 60}
+	tests := []struct {
+		qh   QuietHours
+		t    time.Time
+		want bool
+	}{
+		{QuietHours{}, at(12, 0), false},
+		{overnight, at(21, 59), false},
+		{overnight, at(22, 0), true},
+		{overnight, at(3, 0), true},
+		{overnight, at(7, 0), false},
+		{daytime, at(8, 59), false},
+		{daytime, at(12, 0), true},
+		{daytime, at(17, 0), false},
+	}
+
+	for _, data := range tests {
+		got := data.qh.contains(data.t)
+		if got != data.want {
+			t.Errorf("%v.contains(%v)\nGot : '%v'\nWant: '%v'", data.qh, data.t.Format("15:04"), got, data.want)
+		}
+	}
+}
+
+func TestNotifyRules(t *testing.T) {
+	now := time.Date(2017, 1, 1, 12, 0, 0, 0, time.Local)
+	general := &Channel{name: "general"}
+	alerts := &Channel{name: "alerts"}
+	random := &Channel{name: "random"}
+	im := &Channel{name: "bob", user: "U123"}
+	mpim := &Channel{name: "alice, bob", mpim: true}
+
+	nr := newNotifyRules(NotifyConfig{
+		Channels: map[string]NotifyLevel{"alerts": NotifyAll, "random": NotifyMuted},
+		Keywords: []string{"deploy"},
+		Throttle: 10 * time.Second,
+	})
+	tests := []struct {
+		cl      *Channel
+		mention bool
+		content string
+		at      time.Duration
+		want    bool
+	}{
+		{general, false, "hello", 0, false},
+		{general, true, "@me hello", 0, true},
+		{general, true, "@me again", 5 * time.Second, false}, // Throttled
+		{general, true, "@me again", 10 * time.Second, true},
+		{alerts, false, "disk full", 0, true},
+		{random, true, "@me hello", 0, false},
+		{im, false, "hello", 0, true},
+		{mpim, false, "hello", 0, true},
+		{&Channel{name: "ops"}, false, "starting the deploy", 0, true},
+	}
+
+	for _, data := range tests {
+		got := nr.allow(data.cl, "", data.mention, data.content, now.Add(data.at))
+		if got != data.want {
+			t.Errorf("allow(%v, %v, %q)\nGot : '%v'\nWant: '%v'", data.cl.name, data.mention, data.content, got, data.want)
+		}
+	}
+
+	quiet := newNotifyRules(NotifyConfig{Level: NotifyAll, Quiet: QuietHours{11 * 60, 13 * 60}})
+	if quiet.allow(im, "", true, "hello", now) {
+		t.Errorf("Got 'true', Wanted: 'false'")
+	}
+}
+
+func TestNotifyRulesDuplicates(t *testing.T) {
+	now := time.Date(2017, 1, 1, 12, 0, 0, 0, time.Local)
+	general := &Channel{name: "general"}
+	random := &Channel{name: "random"}
+
+	nr := newNotifyRules(NotifyConfig{Level: NotifyAll})
+	tests := []struct {
+		cl   *Channel
+		ts   string
+		want bool
+	}{
+		{general, "1.000001", true},
+		{general, "1.000001", false}, // Same message from the desktop notification
+		{random, "1.000001", true},
+		{general, "2.000001", true},
+		{general, "", true}, // Unknown
+		{general, "", true},
+	}
+	for _, data := range tests {
+		if got := nr.allow(data.cl, data.ts, false, "hello", now); got != data.want {
+			t.Errorf("allow(%v, %v)\nGot : '%v'\nWant: '%v'", data.cl.name, data.ts, got, data.want)
+		}
+	}
+}
+
+func TestNotifyRulesNames(t *testing.T) {
+	now := time.Date(2017, 1, 1, 12, 0, 0, 0, time.Local)
+	apis := &userApis{users: testUsers(t)}
+	acme := &team{name: "acme", apis: apis}
+	other := &team{name: "other", apis: apis}
+	acmeBob := acme.newIM("D1", "U2")
+	otherBob := other.newIM("D2", "U2")
+	acmeCarol := acme.newIM("D3", "U3")
+	acmeGeneral := &Channel{name: "general", team: acme}
+	otherGeneral := &Channel{name: "general", team: other}
+	acmeRandom := &Channel{name: "random", team: acme}
+	otherRandom := &Channel{name: "random", team: other}
+
+	nr := newNotifyRules(NotifyConfig{
+		Level:    NotifyAll,
+		Channels: map[string]NotifyLevel{"bob": NotifyMuted, "acme/general": NotifyMuted, "random": NotifyMuted, "other/random": NotifyAll},
+	})
+	tests := []struct {
+		cl   *Channel
+		want bool
+	}{
+		{acmeBob, false},
+		{otherBob, false},
+		{acmeCarol, true},
+		{acmeGeneral, false},
+		{otherGeneral, true},
+		{acmeRandom, false},
+		{otherRandom, true}, // Team level wins
+	}
+	for _, data := range tests {
+		if got := nr.allow(data.cl, "", false, "hello", now); got != data.want {
+			t.Errorf("allow(%v/%v)\nGot : '%v'\nWant: '%v'", data.cl.team.name, data.cl.name, got, data.want)
+		}
+	}
+}
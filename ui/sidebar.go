@@ -0,0 +1,332 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"github.com/nsf/termbox-go"
+	"github.com/mattn/go-runewidth"
+)
+
+const sidebarWidth = 28
+
+// Order of channels within a sidebar section
+type SortOrder string
+
+const (
+	SortByName   SortOrder = "name"
+	SortByRecent SortOrder = "recent"
+	SortByUnread SortOrder = "unread"
+)
+
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch o := SortOrder(s); o {
+	case SortByName, SortByRecent, SortByUnread:
+		return o, nil
+	}
+	return "", fmt.Errorf("unknown sort order '%v' (want name, recent or unread)", s)
+}
+
+func (so SortOrder) next() SortOrder {
+	switch so {
+	case SortByName:
+		return SortByRecent
+	case SortByRecent:
+		return SortByUnread
+	}
+	return SortByName
+}
+
+func sortChannels(chls []*Channel, order SortOrder) {
+	sort.SliceStable(chls, func(i, j int) bool {
+		a, b := chls[i], chls[j]
+		switch {
+		case order == SortByUnread && a.mentions != b.mentions:
+			return a.mentions > b.mentions
+		case order == SortByUnread && a.unread != b.unread:
+			return a.unread > b.unread
+		case order == SortByRecent && !a.recency().Equal(b.recency()):
+			return a.recency().After(b.recency())
+		}
+		return strings.ToLower(a.name) < strings.ToLower(b.name)
+	})
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Channels starred locally, persisted as one ID per line
+type Stars struct {
+	path string
+	ids  map[string]bool
+}
+
+// Loads stars from path, a missing file has no stars
+func LoadStars(path string) (*Stars, error) {
+	s := &Stars{path: path, ids: make(map[string]bool)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	for _, id := range strings.Fields(string(data)) {
+		s.ids[id] = true
+	}
+	return s, nil
+}
+
+func (s *Stars) Has(id string) bool {
+	return s.ids[id]
+}
+
+// Stars or unstars the channel & saves the change
+func (s *Stars) Toggle(id string) error {
+	if s.ids[id] {
+		delete(s.ids, id)
+	} else {
+		s.ids[id] = true
+	}
+
+	ids := make([]string, 0, len(s.ids))
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ioutil.WriteFile(s.path, []byte(strings.Join(ids, "\n")), 0600)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type sidebarSection int
+
+const (
+	sectionStarred sidebarSection = iota
+	sectionChannels
+	sectionPrivate
+	sectionDirect
+	sectionCount
+)
+
+func (s sidebarSection) String() string {
+	switch s {
+	case sectionStarred:
+		return "Starred"
+	case sectionChannels:
+		return "Channels"
+	case sectionPrivate:
+		return "Private"
+	default:
+		return "Direct Messages"
+	}
+}
+
+func sectionOf(cl *Channel, stars *Stars) sidebarSection {
+	switch {
+	case stars.Has(cl.id):
+		return sectionStarred
+	case cl.user != "", cl.mpim:
+		return sectionDirect
+	case strings.HasPrefix(cl.id, "G"):
+		return sectionPrivate
+	}
+	return sectionChannels
+}
+
+// A section header or a channel within a section
+type sidebarRow struct {
+	section sidebarSection
+	cl      *Channel // Nil for headers
+}
+
+// Lists the channels of the current team in collapsible sections
+type Sidebar struct {
+	ctrl      Controller
+	status    *Status
+	stars     *Stars
+	order     SortOrder
+	collapsed [sectionCount]bool
+	rows      []sidebarRow
+	team      *team // Team the rows were built for
+	stale     bool  // Rows need rebuilding as channels, counts, names, stars or the order have changed
+	pos       int
+	offset    int // First visible row
+	focused   bool
+}
+
+func NewSidebar(ctrl Controller, status *Status, stars *Stars, order SortOrder) *Sidebar {
+	if order == "" {
+		order = SortByName
+	}
+	return &Sidebar{ ctrl: ctrl, status: status, stars: stars, order: order }
+}
+
+// Moves the selection up (negative) or down
+func (sb *Sidebar) move(inc int) {
+	sb.refresh()
+	if pos := sb.pos + inc; pos >= 0 && pos < len(sb.rows) {
+		sb.pos = pos
+	}
+	sb.ctrl.Redraw()
+}
+
+// Switches to the selected channel, or collapses or expands the selected section
+func (sb *Sidebar) open() {
+	sb.refresh()
+	row, ok := sb.current()
+	if ok && row.cl != nil {
+		sb.focused = false
+		sb.ctrl.SwitchChannel(row.cl)
+		return
+	}
+	sb.toggleSection()
+}
+
+// Collapses or expands the selected section
+func (sb *Sidebar) toggleSection() {
+	sb.refresh()
+	if row, ok := sb.current(); ok && row.cl == nil {
+		sb.collapsed[row.section] = !sb.collapsed[row.section]
+		sb.invalidate()
+	}
+	sb.ctrl.Redraw()
+}
+
+// Stars or unstars the selected channel, keeping it selected
+func (sb *Sidebar) toggleStar() {
+	sb.refresh()
+	if row, ok := sb.current(); ok && row.cl != nil {
+		if err := sb.stars.Toggle(row.cl.id); err != nil {
+			sb.status.msg, sb.status.info = fmt.Sprintf("Unable to save stars: %v", err), false
+		}
+		sb.build()
+		sb.selectChannel(row.cl)
+	}
+	sb.ctrl.Redraw()
+}
+
+func (sb *Sidebar) current() (sidebarRow, bool) {
+	if sb.pos < len(sb.rows) {
+		return sb.rows[sb.pos], true
+	}
+	return sidebarRow{}, false
+}
+
+func (sb *Sidebar) selectChannel(cl *Channel) {
+	for i, row := range sb.rows {
+		if row.cl == cl {
+			sb.pos = i
+		}
+	}
+}
+
+// Marks the rows for rebuilding before they are next used
+func (sb *Sidebar) invalidate() {
+	sb.stale = true
+}
+
+// Rebuilds the rows if they are stale or were built for another team
+func (sb *Sidebar) refresh() {
+	if sb.stale || sb.team != sb.status.team {
+		sb.build()
+	}
+}
+
+// Groups & sorts the team's channels into rows, omitting empty sections & the channels of collapsed ones
+func (sb *Sidebar) build() {
+	sb.team, sb.stale = sb.status.team, false
+	var sections [sectionCount][]*Channel
+	for _, cl := range sb.status.team.chls.chls {
+		s := sectionOf(cl, sb.stars)
+		sections[s] = append(sections[s], cl)
+	}
+
+	sb.rows = sb.rows[:0]
+	for s, chls := range sections {
+		if len(chls) == 0 {
+			continue
+		}
+		sb.rows = append(sb.rows, sidebarRow{section: sidebarSection(s)})
+		if sb.collapsed[s] {
+			continue
+		}
+		sortChannels(chls, sb.order)
+		for _, cl := range chls {
+			sb.rows = append(sb.rows, sidebarRow{section: sidebarSection(s), cl: cl})
+		}
+	}
+	if sb.pos >= len(sb.rows) {
+		sb.pos = 0
+	}
+}
+
+// Draws the sidebar in the region x0 <= x < x1, 0 <= y < h
+func (sb *Sidebar) Draw(x0, x1, h int, term Terminal) {
+	sb.refresh()
+	t := sb.status.team
+	users := t.apis.GetUserList()
+
+	borderFg := lineColour
+	if sb.focused {
+		borderFg = termbox.ColorWhite
+	}
+	printBorder(x0, 0, x1, h, term)
+	printString(runewidth.Truncate(fmt.Sprintf(" %v (by %v) ", t.name, sb.order), x1-x0-3, "… "), x0+2, 0, borderFg, coldef, term)
+
+	x, w := x0+1, x1-x0-2
+	sb.offset = scrollWindow(sb.pos, sb.offset, h-2)
+	y := 1
+	for i := sb.offset; i < len(sb.rows) && y < h-1; i++ {
+		row := sb.rows[i]
+		fg, bg := coldef, coldef
+		if sb.focused && sb.pos == i {
+			fg, bg = termbox.ColorWhite, termbox.ColorYellow
+		}
+
+		if row.cl == nil {
+			arrow := "▾"
+			if sb.collapsed[row.section] {
+				arrow = "▸"
+			}
+			printString(runewidth.FillRight(arrow + " " + row.section.String(), w), x, y, fg | termbox.AttrBold, bg, term)
+			y++
+			continue
+		}
+
+		cl := row.cl
+		var pos int
+		switch {
+		case cl.user != "":
+			pos = printUserPresence(users.GetPresence(cl.user), x+1, y, term)
+		case cl.mpim:
+			pos = printString("+", x+1, y, termbox.ColorCyan, coldef, term)
+		default:
+			pos = printString("#", x+1, y, lineColour, coldef, term)
+		}
+
+		badge := ""
+		switch {
+		case cl.mentions > 0:
+			badge = fmt.Sprintf("@%v", cl.mentions)
+		case cl.unread > 0:
+			badge = fmt.Sprintf("%v", cl.unread)
+		}
+		if cl.unread > 0 && bg == coldef {
+			fg = termbox.ColorWhite | termbox.AttrBold
+		}
+		if cl == t.chl {
+			fg |= termbox.AttrUnderline
+		}
+		nameWidth := x + w - pos - 1 - runewidth.StringWidth(badge) - 1
+		printString(runewidth.FillRight(runewidth.Truncate(cl.name, nameWidth, "…"), nameWidth), pos+1, y, fg, bg, term)
+
+		badgeFg := termbox.ColorWhite
+		if cl.mentions > 0 {
+			badgeFg = mentionColour
+		}
+		printString(badge, x+w-runewidth.StringWidth(badge), y, badgeFg, coldef, term)
+		y++
+	}
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func names(chls []*Channel) []string {
+	ns := []string{}
+	for _, cl := range chls {
+		ns = append(ns, cl.name)
+	}
+	return ns
+}
+
+func TestSortChannels(t *testing.T) {
+	now := time.Now()
+	chls := []*Channel{
+		{name: "b", unread: 2, viewed: now.Add(-time.Hour)},
+		{name: "A", viewed: now},
+		{name: "c", unread: 1, mentions: 1},
+		{name: "d", unread: 5, viewed: now.Add(-time.Minute)},
+	}
+	tests := []struct {
+		order SortOrder
+		want  []string
+	}{
+		{SortByName, []string{"A", "b", "c", "d"}},
+		{SortByRecent, []string{"A", "d", "b", "c"}},
+		{SortByUnread, []string{"c", "d", "b", "A"}},
+	}
+
+	for _, data := range tests {
+		sortChannels(chls, data.order)
+		if got := names(chls); !reflect.DeepEqual(got, data.want) {
+			t.Errorf("sortChannels(%v)\nGot : %q\nWant: %q", data.order, got, data.want)
+		}
+	}
+}
+
+func TestStars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosslyn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stars")
+
+	s, err := LoadStars(path)
+	if err != nil || s.Has("C1") {
+		t.Fatalf("Missing file should have no stars: %v", err)
+	}
+	if err := s.Toggle("C1"); err != nil {
+		t.Fatal(err)
+	}
+	s.Toggle("C2")
+	s.Toggle("C2")
+
+	s, err = LoadStars(path)
+	if err != nil || !s.Has("C1") || s.Has("C2") {
+		t.Errorf("Got: %v (%v), Wanted: [C1]", s.ids, err)
+	}
+}
+
+func TestSidebarSections(t *testing.T) {
+	chls := &ChannelList{}
+	for _, cl := range []*Channel{
+		{id: "C1", name: "general"},
+		{id: "C2", name: "random"},
+		{id: "G1", name: "secret"},
+		{id: "G2", name: "bob, carol", mpim: true},
+		{id: "D1", name: "alice", user: "U1"},
+	} {
+		chls.add(cl)
+	}
+	stars := &Stars{ids: map[string]bool{"C2": true}}
+	sb := NewSidebar(nil, &Status{team: &team{chls: chls}}, stars, "")
+	sb.collapsed[sectionPrivate] = true
+	sb.build()
+
+	var got []string
+	for _, row := range sb.rows {
+		if row.cl == nil {
+			got = append(got, row.section.String())
+		} else {
+			got = append(got, "  "+row.cl.name)
+		}
+	}
+	want := []string{"Starred", "  random", "Channels", "  general", "Private", "Direct Messages", "  alice", "  bob, carol"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got : %q\nWant: %q", got, want)
+	}
+}
+
+func TestSidebarRefresh(t *testing.T) {
+	chls := &ChannelList{}
+	chls.add(&Channel{id: "C1", name: "general"})
+	status := &Status{team: &team{chls: chls}}
+	sb := NewSidebar(nil, status, &Stars{ids: map[string]bool{}}, "")
+	sb.refresh()
+	if len(sb.rows) != 2 {
+		t.Fatalf("Got : %v rows\nWant: 2", len(sb.rows))
+	}
+
+	// Rows are kept until invalidated
+	chls.add(&Channel{id: "C2", name: "random"})
+	sb.refresh()
+	if len(sb.rows) != 2 {
+		t.Errorf("Got : %v rows\nWant: 2", len(sb.rows))
+	}
+	sb.invalidate()
+	sb.refresh()
+	if len(sb.rows) != 3 {
+		t.Errorf("Got : %v rows\nWant: 3", len(sb.rows))
+	}
+
+	// Switching team rebuilds
+	other := &ChannelList{}
+	other.add(&Channel{id: "D1", name: "alice", user: "U1"})
+	status.team = &team{chls: other}
+	sb.refresh()
+	if len(sb.rows) != 2 || sb.rows[1].cl.name != "alice" {
+		t.Errorf("Got : %v rows\nWant: alice", len(sb.rows))
+	}
+}
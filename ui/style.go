@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"unicode"
 )
 
@@ -52,6 +53,7 @@ const (
 	Variable
 	Emoji
 	Link
+	CustomEmoji
 	Unknown
 )
 
@@ -80,6 +82,8 @@ func (f fmtType) String() string {
 		return "Emoji"
 	case Link:
 		return "Link"
+	case CustomEmoji:
+		return "CustomEmoji"
 	case Unknown:
 		return "Unknown"
 	default:
@@ -90,6 +94,7 @@ func (f fmtType) String() string {
 type Lookup interface {
 	GetUser(user string) string
 	GetChannel(channel string) string
+	GetEmoji(name string) (string, bool) // Resolves aliases & reports whether the emoji is custom
 }
 
 const eof = -1
@@ -100,7 +105,7 @@ var emojiRegex *regexp.Regexp
 
 func init() {
 	slackSeqRegex = regexp.MustCompile("^([#!@])([^|]+)\\|?(.+)?$")
-	emojiRegex = regexp.MustCompile("^[+_a-z0-9]+(?:[+_a-z0-9]+)*$")
+	emojiRegex = regexp.MustCompile("^[-+_'a-z0-9]+$")
 }
 
 type Formatter struct {
@@ -165,14 +170,17 @@ func (fe *Formatter) emoji() {
 			return // Not a valid emoji
 		}
 
-		// TODO: Move this mapping elsewhere
-		switch string(seq) {
-		case "slightly_smiling_face":
-			fe.AddFormattedRunes([]rune {'😊' }, Emoji)
-		case "pizza":
-			fe.AddFormattedRunes([]rune {'🍕' }, Emoji)
+		name, custom := fe.lookup.GetEmoji(string(seq))
+		switch e, ok := lookupEmoji(name); {
+		case custom:
+			fe.AddFormattedRunes([]rune(":"+name+":"), CustomEmoji)
+		case strings.HasPrefix(name, "skin-tone-") && fe.skinTone(name):
+			// Applied to the preceding emoji
+		case ok:
+			fe.AddFormattedRunes([]rune(e), Emoji)
 		default:
-			fe.AddFormattedRunes(seq, Emoji)
+			fe.AddRune(':')
+			return // Unknown, e.g. "10:30-11:30", so the closing colon may start an emoji
 		}
 
 		fe.Discard(len(seq) + 1)
@@ -183,6 +191,25 @@ func (fe *Formatter) emoji() {
 	fe.AddRune(':')
 }
 
+// Replaces the emoji immediately before a ":skin-tone-N:" with its toned variant, returns false if there isn't one
+func (fe *Formatter) skinTone(name string) bool {
+	if len(fe.formats) == 0 {
+		return false
+	}
+	last := fe.formats[len(fe.formats)-1]
+	if last.Type() != Emoji || last.End() != len(fe.content) {
+		return false
+	}
+	toned, ok := applySkinTone(string(fe.content[last.Start():]), name)
+	if !ok {
+		return false
+	}
+	fe.content = fe.content[:last.Start()]
+	fe.formats = fe.formats[:len(fe.formats)-1]
+	fe.AddFormattedRunes([]rune(toned), Emoji)
+	return true
+}
+
 func (fe *Formatter) basicMarkdown(r rune) {
 
 	// Found markdown run
@@ -357,6 +384,10 @@ func (fe *Formatter) Lookup(f fmtType, value string) string {
 	case _Channel:
 		return "#" + fe.lookup.GetChannel(value)
 	case Variable:
+		switch value {
+		case "here", "channel", "everyone":
+			return "@" + value
+		}
 		return value
 	}
 	return value
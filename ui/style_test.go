@@ -98,9 +98,22 @@ func TestFormatExtractor(t *testing.T) {
 		{"```~ preformat ~```", "\n~ preformat ~\n", fmts(NewFormat(1, 14, Preformatted))},
 
 		// Emoji
-		{":emo_ji:", "emo_ji", fmts(NewFormat(0, 6, Emoji))},
-		{":+1:", "+1", fmts(NewFormat(0, 2, Emoji))},
+		{":emo_ji:", ":emo_ji:", make([]format, 0)},
+		{":+1:", "👍", fmts(NewFormat(0, 1, Emoji))},
+		{":pizza:", "🍕", fmts(NewFormat(0, 1, Emoji))},
+		{":t-rex:", "🦖", fmts(NewFormat(0, 1, Emoji))},
+		{":flag-gb:", "🇬🇧", fmts(NewFormat(0, 2, Emoji))},
+		{":man-woman-boy:", "👨\u200d👩\u200d👦", fmts(NewFormat(0, 5, Emoji))},
+		{":wave::skin-tone-3:", "👋🏼", fmts(NewFormat(0, 2, Emoji))},
+		{":male-technologist::skin-tone-5:", "👨🏾\u200d💻", fmts(NewFormat(0, 4, Emoji))},
+		{":emo_ji::skin-tone-2:", ":emo_ji:🏻", fmts(NewFormat(8, 9, Emoji))},
+		{":partyparrot:", ":partyparrot:", fmts(NewFormat(0, 13, CustomEmoji))},
+		{":parrot:", ":partyparrot:", fmts(NewFormat(0, 13, CustomEmoji))},
+		{":yes:", "✅", fmts(NewFormat(0, 1, Emoji))},
 		{":not an emo ji:", ":not an emo ji:", make([]format, 0)},
+		{"standup 10:30-11:30 today", "standup 10:30-11:30 today", make([]format, 0)},
+		{"ratio 1:2-3:4", "ratio 1:2-3:4", make([]format, 0)},
+		{"at 1:2-3:pizza:", "at 1:2-3🍕", fmts(NewFormat(8, 9, Emoji))},
 
 		// Aggregate
 		{"<@id|user> <!here|@here> *Check* _this_ `out`!:\n```&<>_```\n *in my* <#id|channel>.",
@@ -146,4 +159,16 @@ func (sl *stubLookup) GetUser(id string) string {
 
 func (sl *stubLookup) GetChannel(id string) string {
 	return "channel"
+}
+
+func (sl *stubLookup) GetEmoji(name string) (string, bool) {
+	switch name {
+	case "partyparrot":
+		return name, true
+	case "parrot":
+		return "partyparrot", true
+	case "yes":
+		return "white_check_mark", false
+	}
+	return name, false
 }
\ No newline at end of file
@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"github.com/g-dx/rosslyn/slack"
+)
+
+// A single Slack workspace, its connection & channels
+type team struct {
+	id, name string
+	self     string // Our own user ID
+	selfName string // & name
+	presence string // Our own presence in this team
+
+	apis  slack.Apis
+	rtm   *slack.RtmConnection
+	emoji *slack.EmojiList // Custom emoji
+
+	chls *ChannelList
+	chl  *Channel // Last channel viewed in this team
+
+	public []slack.Conversation // Public channels, refreshed for completing /join & #channel
+	listed time.Time            // When public was last refreshed
+
+	chlsView *ChannelSelectionView
+}
+
+// Event received on a team's RTM connection
+type teamEvent struct {
+	t   *team
+	evt slack.Event
+}
+
+func (ctrl *controller) newTeam(apis slack.Apis) *team {
+
+	// Open connection
+	info, conn := apis.RtmConnect()
+	t := &team{
+		id:       info.Team.ID,
+		name:     info.Team.Name,
+		self:     info.Self.ID,
+		selfName: info.Self.Name,
+		apis:     apis,
+		rtm:      slack.NewRtmConnection(ctrl.logger, conn),
+		chls:     &ChannelList{},
+	}
+
+	// Load our own presence
+	go func() {
+		presence, err := apis.GetPresence()
+		ctrl.userEvts <- func() {
+			if err != nil {
+				ctrl.setStatus("Unable to load presence for %v: %v", t.name, err)
+				return
+			}
+			t.presence = presence
+			ctrl.Redraw()
+		}
+	}()
+
+	// Load custom emoji now so messages can be formatted, they are optional so carry on without them
+	emoji, err := apis.GetEmojiList()
+	if err != nil {
+		ctrl.logger.Printf("Unable to load custom emoji for %v: %v", t.name, err)
+		emoji = &slack.EmojiList{}
+	}
+	t.emoji = emoji
+
+	// Process groups
+	grpAndChl := apis.GetGroupAndChannelList()
+	for _, grp := range grpAndChl.Groups.Groups {
+		cl := &Channel { id: grp.ID, name: grp.NameNormalized, team: t, mpim: grp.IsMpim,
+			topic: grp.Topic.Value, purpose: grp.Purpose.Value }
+		if grp.IsMpim {
+			ctrl.loadMembers(cl) // Not included in list
+		}
+		go func() {
+			info := apis.GetGroupInfo(cl.id)
+			ctrl.userEvts <- func() {
+				cl.setReadState(info.Group.LastRead, info.Group.UnreadCountDisplay, info.Group.MentionCountDisplay)
+				cl.members = len(info.Group.Members)
+				ctrl.onUnreadChanged(t)
+			}
+		}()
+		t.chls.add(cl)
+	}
+
+	// Process channels
+	for _, cl := range grpAndChl.Channels.Channels {
+		// Only add channels we are a member of
+		if cl.IsMember {
+			chl := &Channel { id: cl.ID, name: cl.NameNormalized, team: t,
+				topic: cl.Topic.Value, purpose: cl.Purpose.Value, members: cl.NumMembers }
+			go func() {
+				info := apis.GetChannelInfo(chl.id)
+				ctrl.userEvts <- func() {
+					chl.setReadState(info.Channel.LastRead, info.Channel.UnreadCountDisplay, info.Channel.MentionCountDisplay)
+					ctrl.onUnreadChanged(t)
+				}
+			}()
+			t.chls.add(chl)
+		}
+	}
+
+	// Process IM
+	for _, im := range grpAndChl.IM.Ims {
+		// Skip our own IM, Slack lists it for notes to self
+		if im.User != t.self && apis.GetUserList().IsActive(im.User) {
+			cl := t.newIM(im.ID, im.User)
+			go func() {
+				info, err := apis.GetConversationInfo(cl.id)
+				ctrl.userEvts <- func() {
+					if err != nil {
+						ctrl.logger.Printf("Unable to load info for '%v': %v", cl.id, err)
+						return
+					}
+					cl.setReadState(info.Channel.LastRead, info.Channel.UnreadCountDisplay, info.Channel.MentionCountDisplay)
+					ctrl.onUnreadChanged(t)
+				}
+			}()
+			t.chls.add(cl)
+		}
+	}
+
+	t.chlsView = NewChannelListView(ctrl, t.chls, apis.GetUserList(), t.name)
+
+	// Start in the default channel, otherwise fallback to the first channel
+	t.chl = t.chls.findByName(ctrl.cfg.DefaultChannel)
+	if t.chl == nil && t.chls.Size() > 0 {
+		t.chl = t.chls.chls[0]
+	}
+
+	// Forward events from connection
+	go func() {
+		for {
+			ctrl.slackEvts <- teamEvent{t, <-t.rtm.ReadEvent()}
+		}
+	}()
+	return t
+}
+
+// Returns the public channels listed last, or those at startup until they have been
+func (t *team) publicChannels() []slack.Conversation {
+	if t.public != nil {
+		return t.public
+	}
+	var chls []slack.Conversation
+	for _, cl := range t.apis.GetGroupAndChannelList().Channels.Channels {
+		chls = append(chls, slack.Conversation{ID: cl.ID, Name: cl.Name, IsMember: cl.IsMember})
+	}
+	return chls
+}
+
+// Resolves users, channels & custom emoji when formatting & encoding messages
+func (t *team) lookup() *slackLookup {
+	return &slackLookup{t.apis.GetUserList(), t.emoji, t.chls, t.apis.GetGroupAndChannelList()}
+}
+
+func (t *team) unread() int {
+	n := 0
+	for _, cl := range t.chls.chls {
+		n += cl.unread
+	}
+	return n
+}
+
+func (t *team) newIM(id, user string) *Channel {
+	users := t.apis.GetUserList()
+	return &Channel{id: id, name: fmt.Sprintf("%-25v (%v)", users.GetRealName(user), users.GetName(user)), user: user, team: t}
+}
+
+// Names a "multiple person IM" after its members, excluding ourselves
+func (t *team) mpimName(members []string) string {
+	users := t.apis.GetUserList()
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		if m != t.self {
+			names = append(names, users.GetName(m))
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Creates a channel for the conversation described by the info
+func (t *team) newChannel(info *slack.ConversationInfo, members []string) *Channel {
+	switch {
+	case info.Channel.IsIm:
+		return t.newIM(info.Channel.ID, info.Channel.User)
+	case info.Channel.IsMpim:
+		return &Channel{id: info.Channel.ID, name: t.mpimName(members), team: t, mpim: true, members: len(members)}
+	default:
+		return &Channel{id: info.Channel.ID, name: info.Channel.NameNormalized, team: t,
+			topic: info.Channel.Topic.Value, purpose: info.Channel.Purpose.Value, members: info.Channel.NumMembers}
+	}
+}
+
+// Loads the members of an MPIM in the background & renames it after them
+func (ctrl *controller) loadMembers(cl *Channel) {
+	go func() {
+		members, err := cl.team.apis.GetConversationMembers(cl.id)
+		ctrl.userEvts <- func() {
+			if err != nil {
+				ctrl.logger.Printf("Unable to load members for '%v': %v", cl.id, err)
+				return
+			}
+			cl.name = cl.team.mpimName(members)
+			cl.members = len(members)
+			ctrl.sidebar.invalidate()
+			ctrl.Redraw()
+		}
+	}()
+}
+
+// Loads an unknown conversation in the background, adds it to the team & calls f
+func (ctrl *controller) loadConversation(t *team, id string, f func(cl *Channel)) {
+	if _, cl := t.chls.find(id); cl != nil {
+		f(cl) // Already loaded
+		return
+	}
+	go func() {
+		info, err := t.apis.GetConversationInfo(id)
+		var members []string
+		if err == nil && info.Channel.IsMpim {
+			members, err = t.apis.GetConversationMembers(id)
+		}
+		ctrl.userEvts <- func() {
+			if err != nil {
+				ctrl.setStatus("Unable to load conversation '%v': %v", id, err)
+				return
+			}
+			_, cl := t.chls.find(id)
+			if cl == nil {
+				cl = t.newChannel(info, members)
+				cl.setReadState(info.Channel.LastRead, info.Channel.UnreadCountDisplay, info.Channel.MentionCountDisplay)
+				t.chls.add(cl)
+				ctrl.sidebar.invalidate()
+			}
+			f(cl)
+		}
+	}()
+}
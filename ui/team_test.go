@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"testing"
+	"github.com/g-dx/rosslyn/slack"
+)
+
+// Serves a fixed user list, other calls panic
+type userApis struct {
+	slack.Apis
+	users *slack.UserList
+}
+
+func (a *userApis) GetUserList() *slack.UserList {
+	return a.users
+}
+
+func TestMpimName(t *testing.T) {
+	tests := []struct {
+		members []string
+		want    string
+	}{
+		{[]string{"U1", "U2", "U3"}, "bob, carol"},
+		{[]string{"U3", "U1", "U2"}, "bob, carol"},
+		{[]string{"U4", "U2", "U3"}, "bob, carol, dave"},
+		{[]string{"U1"}, ""},
+	}
+	tm := &team{self: "U1", apis: &userApis{users: testUsers(t)}}
+	for _, test := range tests {
+		if got := tm.mpimName(test.members); got != test.want {
+			t.Errorf("mpimName(%v)\nGot : %q\nWant: %q", test.members, got, test.want)
+		}
+	}
+}
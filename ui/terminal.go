@@ -40,6 +40,26 @@ func (t *terminal) SetCell(r rune, x, y int, fg, bg termbox.Attribute) int {
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+// Draws into the rows y0 <= y < y0+h of another terminal as if they were the whole screen, dropping cells outside
+type clipTerminal struct {
+	Terminal
+	y0, h int
+}
+
+func (t *clipTerminal) Size() (int, int) {
+	w, _ := t.Terminal.Size()
+	return w, t.h
+}
+
+func (t *clipTerminal) SetCell(r rune, x, y int, fg, bg termbox.Attribute) int {
+	if y < 0 || y >= t.h {
+		return runewidth.RuneWidth(r)
+	}
+	return t.Terminal.SetCell(r, x, t.y0+y, fg, bg)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 type nullTerminal struct {}
 
 func (t *nullTerminal) Clear(fg, bg termbox.Attribute) {}
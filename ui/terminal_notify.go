@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Notification backends which work through the terminal & so survive SSH & tmux
+const (
+	NotifierAuto    = "auto"
+	NotifierDesktop = "desktop"
+	NotifierBell    = "bell"
+	NotifierOSC9    = "osc9"
+	NotifierOSC777  = "osc777"
+	NotifierTmux    = "tmux"
+)
+
+// Creates the named Notifier, "auto" picks one suitable for the environment
+func NewNotifier(backend string) (Notifier, error) {
+	if backend == NotifierAuto {
+		backend = detectNotifier(os.Getenv)
+	}
+	inTmux := os.Getenv("TMUX") != ""
+	switch backend {
+	case NotifierDesktop:
+		return NewDesktopNotifier(), nil
+	case NotifierBell:
+		return &bellNotifier{os.Stdout}, nil
+	case NotifierOSC9:
+		return &oscNotifier{os.Stdout, osc9, inTmux}, nil
+	case NotifierOSC777:
+		return &oscNotifier{os.Stdout, osc777, inTmux}, nil
+	case NotifierTmux:
+		return &tmuxNotifier{}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier '%v' (want auto, desktop, bell, osc9, osc777 or tmux)", backend)
+}
+
+// Picks a backend from the environment. Desktop notifications only work when running locally, remotely we rely on
+// escape sequences for terminals known to support them & otherwise fall back to the bell.
+func detectNotifier(getenv func(string) string) string {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("TMUX") != "":
+		return NotifierTmux
+	case program == "iTerm.app", program == "WezTerm", term == "xterm-kitty":
+		return NotifierOSC9
+	case getenv("VTE_VERSION") != "", strings.HasPrefix(term, "rxvt"):
+		return NotifierOSC777
+	case getenv("SSH_CONNECTION") != "", getenv("SSH_TTY") != "":
+		return NotifierBell
+	}
+	return NotifierDesktop
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type bellNotifier struct {
+	w io.Writer
+}
+
+func (bn *bellNotifier) Notify(title, body string) error {
+	_, err := io.WriteString(bn.w, "\a")
+	return err
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type oscNotifier struct {
+	w      io.Writer
+	format func(title, body string) string
+	tmux   bool // Wrap in a passthrough sequence so tmux forwards it to the outer terminal
+}
+
+func osc9(title, body string) string {
+	return "\x1b]9;" + title + ": " + body + "\a"
+}
+
+func osc777(title, body string) string {
+	return "\x1b]777;notify;" + title + ";" + body + "\a"
+}
+
+func (on *oscNotifier) Notify(title, body string) error {
+	seq := on.format(oscSafe(title), oscSafe(body))
+	if on.tmux {
+		seq = "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	}
+	_, err := io.WriteString(on.w, seq)
+	return err
+}
+
+// Removes anything which could end the sequence early or be interpreted by the terminal. Messages come from other
+// users so must not be able to inject escape sequences. Semicolons are field separators for OSC 777.
+func oscSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ';':
+			return ','
+		case r < 0x20, r == 0x7f, r >= 0x80 && r < 0xa0:
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// How long tmux gets to display a message before it is killed, e.g. if the server is unresponsive
+const tmuxTimeout = 2 * time.Second
+
+type tmuxNotifier struct{}
+
+// Starts tmux & returns without waiting for it, so a slow server can't stall the UI. Only failing to start is reported.
+func (tn *tmuxNotifier) Notify(title, body string) error {
+	// "#" starts a tmux format so must be escaped
+	msg := strings.Replace(oscSafe(title+": "+body), "#", "##", -1)
+	ctx, cancel := context.WithTimeout(context.Background(), tmuxTimeout)
+	cmd := exec.CommandContext(ctx, "tmux", "display-message", msg)
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+	go func() {
+		defer cancel()
+		cmd.Wait()
+	}()
+	return nil
+}
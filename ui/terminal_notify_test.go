@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOscNotifier(t *testing.T) {
+	tests := []struct {
+		format      func(title, body string) string
+		tmux        bool
+		title, body string
+		want        string
+	}{
+		{osc9, false, "New message", "hello", "\x1b]9;New message: hello\a"},
+		{osc777, false, "New message", "hello", "\x1b]777;notify;New message;hello\a"},
+		{osc777, false, "a;b", "c\x1b]0;pwned\ad", "\x1b]777;notify;a,b;c ]0,pwned d\a"},
+		{osc9, true, "New message", "hello", "\x1bPtmux;\x1b\x1b]9;New message: hello\a\x1b\\"},
+	}
+
+	for _, data := range tests {
+		var buf bytes.Buffer
+		on := &oscNotifier{&buf, data.format, data.tmux}
+		if err := on.Notify(data.title, data.body); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := buf.String(); got != data.want {
+			t.Errorf("Notify(%q, %q)\nGot : %q\nWant: %q", data.title, data.body, got, data.want)
+		}
+	}
+}
+
+func TestDetectNotifier(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, NotifierDesktop},
+		{map[string]string{"TERM": "xterm-256color"}, NotifierDesktop},
+		{map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0", "TERM_PROGRAM": "iTerm.app"}, NotifierTmux},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, NotifierOSC9},
+		{map[string]string{"TERM": "xterm-kitty"}, NotifierOSC9},
+		{map[string]string{"VTE_VERSION": "6003"}, NotifierOSC777},
+		{map[string]string{"TERM": "rxvt-unicode-256color"}, NotifierOSC777},
+		{map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"}, NotifierBell},
+	}
+
+	for _, data := range tests {
+		got := detectNotifier(func(k string) string { return data.env[k] })
+		if got != data.want {
+			t.Errorf("detectNotifier(%v)\nGot : '%v'\nWant: '%v'", data.env, got, data.want)
+		}
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"time"
 	"hash/fnv"
 	"strconv"
+	"github.com/mattn/go-runewidth"
+	"sort"
+	"html"
 )
 
 type View interface {
@@ -21,51 +24,113 @@ type ChannelSelectionView struct {
 	ctrl Controller
 	chls *ChannelList
 	users *slack.UserList
+	title string
+	filter []rune
+	matches []*Channel
 	pos int
+	offset int // First visible match
 }
 
-func NewChannelListView(ctrl Controller, chls *ChannelList, users *slack.UserList) *ChannelSelectionView {
-	return &ChannelSelectionView{ ctrl: ctrl, chls: chls, users : users  }
+func NewChannelListView(ctrl Controller, chls *ChannelList, users *slack.UserList, team string) *ChannelSelectionView {
+	return &ChannelSelectionView{ ctrl: ctrl, chls: chls, users : users, title: fmt.Sprintf("Channel List - %v", team) }
 }
 
+// Clears the filter & selection ready to be shown again
+func (csv *ChannelSelectionView) reset() {
+	csv.filter = csv.filter[:0]
+	csv.pos, csv.offset = 0, 0
+}
+
+// Edits the filter, other keys are bound to actions
 func (csv *ChannelSelectionView) OnKey(key termbox.Key, r rune) {
 	switch key {
-	case termbox.KeyEnter:
-		csv.ctrl.SwitchChannel(csv.chls.chls[csv.pos])
-	case termbox.KeyArrowUp:
-		csv.up()
-	case termbox.KeyArrowDown:
-		csv.down()
-	case termbox.KeyEsc:
-		csv.ctrl.SwitchChannel(nil)
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		if len(csv.filter) > 0 {
+			csv.filter = csv.filter[:len(csv.filter)-1]
+			csv.pos, csv.offset = 0, 0
+		}
+	case termbox.KeySpace:
+		r = ' '
+		fallthrough
+	default:
+		if r != 0 {
+			csv.filter = append(csv.filter, r)
+			csv.pos, csv.offset = 0, 0
+		}
 	}
+	csv.ctrl.Redraw()
 }
 
-func (csv *ChannelSelectionView) up() {
-	if csv.pos != 0 {
-		csv.pos--
-		csv.ctrl.Redraw()
+// Moves the selection up (negative) or down
+func (csv *ChannelSelectionView) move(inc int) {
+	if pos := csv.pos + inc; pos >= 0 && pos < len(csv.matches) {
+		csv.pos = pos
 	}
+	csv.ctrl.Redraw()
 }
 
-func (csv *ChannelSelectionView) down() {
-	if csv.pos != csv.chls.Size() - 1 {
-		csv.pos++
-		csv.ctrl.Redraw()
+// Switches to the selected channel
+func (csv *ChannelSelectionView) open() {
+	if csv.pos < len(csv.matches) {
+		csv.ctrl.SwitchChannel(csv.matches[csv.pos])
+	}
+}
+
+// Filters channels by name & DMs by username or real name. Best matches come first, followed by those with the most
+// unread messages & then the most recently active.
+func (csv *ChannelSelectionView) match() {
+	type ranked struct {
+		cl *Channel
+		score int
+		recency time.Time
+	}
+	filter := string(csv.filter)
+	var matches []ranked
+	for _, cl := range csv.chls.chls {
+		names := []string{cl.name}
+		if cl.user != "" {
+			names = append(names, csv.users.GetRealName(cl.user))
+		}
+		if score, ok := fuzzyBest(filter, names...); ok {
+			matches = append(matches, ranked{cl, score, cl.recency()})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch {
+		case a.score != b.score:
+			return a.score > b.score
+		case a.cl.unread != b.cl.unread:
+			return a.cl.unread > b.cl.unread
+		default:
+			return a.recency.After(b.recency)
+		}
+	})
+
+	csv.matches = csv.matches[:0]
+	for _, m := range matches {
+		csv.matches = append(csv.matches, m.cl)
+	}
+	if csv.pos >= len(csv.matches) {
+		csv.pos = 0
 	}
 }
 
 func (csv *ChannelSelectionView) Draw(term Terminal) {
+	csv.match()
 
 	term.Clear(coldef, coldef)
 	term.HideCursor()
 
 	w, h := term.Size()
 	printBorder(0, 0, w, h, term)
-	printString("Channel List\n", 2, 1, termbox.ColorWhite | termbox.AttrUnderline, coldef, term)
+	printString(csv.title, 2, 1, termbox.ColorWhite | termbox.AttrUnderline, coldef, term)
+	printString("> " + string(csv.filter), 2, 2, termbox.ColorWhite, coldef, term)
 
-	x, y := 1, 3
-	for i, ch := range csv.chls.chls {
+	x, y := 1, 4
+	csv.offset = scrollWindow(csv.pos, csv.offset, h-5)
+	for i := csv.offset; i < len(csv.matches) && y < h-1; i++ {
+		ch := csv.matches[i]
 		bg := coldef
 		fg := coldef
 
@@ -78,23 +143,23 @@ func (csv *ChannelSelectionView) Draw(term Terminal) {
 		if ch.unread > 0 {
 			unread = fmt.Sprintf("%4v", fmt.Sprintf("(%v)", ch.unread))
 		}
+		mentions := "   "
+		if ch.mentions > 0 {
+			mentions = fmt.Sprintf("%-3v", fmt.Sprintf("@%v", ch.mentions))
+		}
 
 		// Check if this is a user
 		var pos int
 		if ch.id[:1] == "D" {
-			switch csv.users.GetPresence(ch.user) {
-			case "active":
-				pos = printString("●", x, y, termbox.Attribute(30), coldef, term)
-			case "away":
-				pos = printString("○", x, y, termbox.ColorWhite, coldef, term)
-			default:
-				pos = printString("?", x, y, termbox.ColorRed, coldef, term)
-			}
+			pos = printUserPresence(csv.users.GetPresence(ch.user), x, y, term)
+		} else if ch.mpim {
+			pos = printString("+", x, y, termbox.ColorCyan, coldef, term)
 		} else {
 			pos = printString("*", x, y, termbox.ColorYellow, coldef, term)
 		}
 
 		pos = printString(unread, pos+1, y, termbox.ColorWhite, coldef, term)
+		pos = printString(mentions, pos+1, y, mentionColour, coldef, term)
 		printString(ch.name, pos+1, y, fg, bg, term)
 		y++
 	}
@@ -103,69 +168,459 @@ func (csv *ChannelSelectionView) Draw(term Terminal) {
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+type TeamSelectionView struct {
+	ctrl  Controller
+	teams []*team
+	pos   int
+}
+
+func NewTeamSelectionView(ctrl Controller, teams []*team) *TeamSelectionView {
+	return &TeamSelectionView{ ctrl: ctrl, teams: teams }
+}
+
+// Keys are bound to actions
+func (tsv *TeamSelectionView) OnKey(key termbox.Key, r rune) {
+}
+
+// Moves the selection up (negative) or down
+func (tsv *TeamSelectionView) move(inc int) {
+	if pos := tsv.pos + inc; pos >= 0 && pos < len(tsv.teams) {
+		tsv.pos = pos
+	}
+	tsv.ctrl.Redraw()
+}
+
+// Switches to the selected team
+func (tsv *TeamSelectionView) open() {
+	tsv.ctrl.SwitchTeam(tsv.teams[tsv.pos])
+}
+
+func (tsv *TeamSelectionView) Draw(term Terminal) {
+
+	term.Clear(coldef, coldef)
+	term.HideCursor()
+
+	w, h := term.Size()
+	printBorder(0, 0, w, h, term)
+	printString("Workspaces\n", 2, 1, termbox.ColorWhite | termbox.AttrUnderline, coldef, term)
+
+	x, y := 1, 3
+	for i, t := range tsv.teams {
+		bg := coldef
+		fg := coldef
+
+		if tsv.pos == i {
+			bg = termbox.ColorYellow
+			fg = termbox.ColorWhite
+		}
+
+		unread := "     "
+		if t.unread() > 0 {
+			unread = fmt.Sprintf("%5v", fmt.Sprintf("(%v)", t.unread()))
+		}
+
+		pos := printString(unread, x, y, termbox.ColorWhite, coldef, term)
+		printString(t.name, pos+1, y, fg, bg, term)
+		y++
+	}
+	term.Flush()
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type UserSelectionView struct {
+	ctrl     Controller
+	users    *slack.UserList
+	self     string
+	filter   []rune
+	matches  []int // Indices into users.Members
+	selected map[string]bool
+	pos      int
+	offset   int // First visible match
+}
+
+func NewUserSelectionView(ctrl Controller, users *slack.UserList, self string) *UserSelectionView {
+	usv := &UserSelectionView{ ctrl: ctrl, users: users, self: self, selected: make(map[string]bool) }
+	usv.match()
+	return usv
+}
+
+// Edits the filter, other keys are bound to actions
+func (usv *UserSelectionView) OnKey(key termbox.Key, r rune) {
+	switch key {
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		if len(usv.filter) > 0 {
+			usv.filter = usv.filter[:len(usv.filter)-1]
+			usv.match()
+			usv.ctrl.Redraw()
+		}
+	case termbox.KeySpace:
+		r = ' '
+		fallthrough
+	default:
+		if r != 0 {
+			usv.filter = append(usv.filter, r)
+			usv.match()
+			usv.ctrl.Redraw()
+		}
+	}
+}
+
+// Moves the selection up (negative) or down
+func (usv *UserSelectionView) move(inc int) {
+	if pos := usv.pos + inc; pos >= 0 && pos < len(usv.matches) {
+		usv.pos = pos
+	}
+	usv.ctrl.Redraw()
+}
+
+// Selects or deselects the current user
+func (usv *UserSelectionView) toggle() {
+	if len(usv.matches) == 0 {
+		return
+	}
+	id := usv.users.Members[usv.matches[usv.pos]].ID
+	if usv.selected[id] {
+		delete(usv.selected, id)
+	} else {
+		usv.selected[id] = true
+	}
+	usv.ctrl.Redraw()
+}
+
+// Opens a conversation with the selected users or the current one if none are selected
+func (usv *UserSelectionView) open() {
+	ids := make([]string, 0, len(usv.selected))
+	for id := range usv.selected {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 && len(usv.matches) > 0 {
+		ids = append(ids, usv.users.Members[usv.matches[usv.pos]].ID)
+	}
+	if len(ids) > 0 {
+		sort.Strings(ids)
+		usv.ctrl.OpenConversation(ids)
+	}
+}
+
+// Filters & ranks users by name, real name & email
+func (usv *UserSelectionView) match() {
+	filter := string(usv.filter)
+	scores := make(map[int]int)
+	usv.matches = usv.matches[:0]
+	for i, m := range usv.users.Members {
+		if m.Deleted || m.ID == usv.self {
+			continue
+		}
+		if score, ok := fuzzyBest(filter, m.Name, m.RealName, m.Profile.Email); ok {
+			scores[i] = score
+			usv.matches = append(usv.matches, i)
+		}
+	}
+	sort.SliceStable(usv.matches, func(i, j int) bool {
+		a, b := usv.matches[i], usv.matches[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return usv.users.Members[a].Name < usv.users.Members[b].Name
+	})
+	usv.pos, usv.offset = 0, 0
+}
+
+func (usv *UserSelectionView) Draw(term Terminal) {
+
+	term.Clear(coldef, coldef)
+	term.HideCursor()
+
+	w, h := term.Size()
+	printBorder(0, 0, w, h, term)
+	x := printString("Select Users (Tab to add, Enter to open): ", 2, 1, termbox.ColorWhite | termbox.AttrUnderline, coldef, term)
+	printString(string(usv.filter), x, 1, termbox.ColorWhite, coldef, term)
+
+	x, y := 1, 3
+	usv.offset = scrollWindow(usv.pos, usv.offset, h-4)
+	for i := usv.offset; i < len(usv.matches) && y < h-1; i++ {
+		m := usv.users.Members[usv.matches[i]]
+		bg := coldef
+		fg := coldef
+
+		if usv.pos == i {
+			bg = termbox.ColorYellow
+			fg = termbox.ColorWhite
+		}
+
+		mark := "[ ]"
+		if usv.selected[m.ID] {
+			mark = "[x]"
+		}
+		pos := printString(mark, x, y, termbox.ColorWhite, coldef, term)
+		pos = printString(fmt.Sprintf("%-25v (%v)", m.RealName, m.Name), pos+1, y, fg, bg, term)
+		printString(m.Profile.Email, pos+1, y, termbox.ColorBlue, coldef, term)
+		y++
+	}
+	term.Flush()
+}
+
+// Returns the offset of the first visible item in a window of size h such that pos is visible
+func scrollWindow(pos, offset, h int) int {
+	switch {
+	case h <= 0:
+		return pos
+	case pos < offset:
+		return pos
+	case pos >= offset+h:
+		return pos-h+1
+	}
+	return offset
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// A view listing help, scrolled by actions
+type helpView interface {
+	View
+	scroll(inc int)
+}
+
+type CommandHelpView struct {
+	ctrl   Controller
+	cmds   []*command
+	offset int
+}
+
+func NewCommandHelpView(ctrl Controller, cmds []*command) *CommandHelpView {
+	return &CommandHelpView{ ctrl: ctrl, cmds: cmds }
+}
+
+// Keys are bound to actions
+func (chv *CommandHelpView) OnKey(key termbox.Key, r rune) {
+}
+
+func (chv *CommandHelpView) scroll(inc int) {
+	if offset := chv.offset + inc; offset >= 0 && offset < len(chv.cmds) {
+		chv.offset = offset
+		chv.ctrl.Redraw()
+	}
+}
+
+func (chv *CommandHelpView) Draw(term Terminal) {
+
+	term.Clear(coldef, coldef)
+	term.HideCursor()
+
+	w, h := term.Size()
+	printBorder(0, 0, w, h, term)
+	printString("Commands (Esc to close)", 2, 1, termbox.ColorWhite | termbox.AttrUnderline, coldef, term)
+
+	width := 0
+	for _, c := range chv.cmds {
+		if n := runewidth.StringWidth(c.usage()); n > width {
+			width = n
+		}
+	}
+
+	x, y := 2, 3
+	for i := chv.offset; i < len(chv.cmds) && y < h-1; i++ {
+		c := chv.cmds[i]
+		printString(runewidth.FillRight(c.usage(), width), x, y, termbox.ColorWhite, coldef, term)
+		printString(c.help, x+width+2, y, coldef, coldef, term)
+		y++
+	}
+	term.Flush()
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Lists the key bindings active where it was opened, by context
+type KeyHelpView struct {
+	ctrl   Controller
+	help   []keyHelp
+	lines  int
+	offset int
+}
+
+func NewKeyHelpView(ctrl Controller, help []keyHelp) *KeyHelpView {
+	khv := &KeyHelpView{ ctrl: ctrl, help: help }
+	for _, kh := range help {
+		khv.lines += len(kh.bindings) + 2
+	}
+	return khv
+}
+
+// Keys are bound to actions
+func (khv *KeyHelpView) OnKey(key termbox.Key, r rune) {
+}
+
+func (khv *KeyHelpView) scroll(inc int) {
+	if offset := khv.offset + inc; offset >= 0 && offset < khv.lines {
+		khv.offset = offset
+		khv.ctrl.Redraw()
+	}
+}
+
+func (khv *KeyHelpView) Draw(term Terminal) {
+
+	term.Clear(coldef, coldef)
+	term.HideCursor()
+
+	w, h := term.Size()
+	printBorder(0, 0, w, h, term)
+	printString("Key Bindings (Esc to close)", 2, 1, termbox.ColorWhite | termbox.AttrUnderline, coldef, term)
+
+	width := 0
+	for _, kh := range khv.help {
+		for _, b := range kh.bindings {
+			if n := runewidth.StringWidth(formatKeys(b.keys)); n > width {
+				width = n
+			}
+		}
+	}
+
+	// Scroll by skipping lines
+	x, y, line := 2, 3, 0
+	printLine := func(f func(y int)) {
+		if line >= khv.offset && y < h-1 {
+			f(y)
+			y++
+		}
+		line++
+	}
+	for _, kh := range khv.help {
+		printLine(func(y int) { printString(kh.context, x, y, lineColour | termbox.AttrBold, coldef, term) })
+		for _, b := range kh.bindings {
+			printLine(func(y int) {
+				printString(runewidth.FillRight(formatKeys(b.keys), width), x+2, y, termbox.ColorWhite, coldef, term)
+				pos := printString(b.action.help, x+width+4, y, coldef, coldef, term)
+				printString("(" + b.action.name + ")", pos+1, y, lineColour, coldef, term)
+			})
+		}
+		printLine(func(int) {})
+	}
+	term.Flush()
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 type ChannelView struct {
 	ctrl Controller
 
 	cl *Channel
+	status *Status
+	lastRead string // Position of "new messages" line
+	timeFormat string // Layout of message times
 	msgLines []int
+	members *MemberPanel // Nil when hidden
 
 	editor EditBox
+	completions *CompletionPopup // Nil when hidden
+	dismissed   string           // Word for which completion was cancelled
+}
+
+func NewChannelView(ctrl Controller, cl *Channel, status *Status, timeFormat string) *ChannelView {
+	if timeFormat == "" {
+		timeFormat = DefaultTimeFormat
+	}
+	cv := &ChannelView{ ctrl: ctrl, cl: cl, status: status, lastRead: cl.lastRead, timeFormat: timeFormat }
+	cv.editor.SetText(cl.draft)
+	return cv
 }
 
-func NewChannelView(ctrl Controller, cl *Channel) *ChannelView {
-	return &ChannelView{ ctrl: ctrl, cl: cl }
+// Keeps any unsent text with the channel to restore when it is next shown
+func (cv *ChannelView) saveDraft() {
+	cv.cl.draft = cv.editor.GetText()
 }
 
+// Inserts typed text, other keys are bound to actions
 func (cv *ChannelView) OnKey(key termbox.Key, r rune) {
-	switch key {
+	if cv.members != nil && cv.members.focused {
+		cv.members.OnKey(key, r)
+		return
+	}
+	switch {
+	case key == termbox.KeySpace:
+		cv.edit(func(eb *EditBox) { eb.InsertRune(' ') })
+	case r != 0:
+		cv.edit(func(eb *EditBox) { eb.InsertRune(r) })
+	}
+}
+
+// Changes the message being typed & the completions for it
+func (cv *ChannelView) edit(f func(eb *EditBox)) {
+	f(&cv.editor)
+	cv.updateCompletions()
+	cv.ctrl.Redraw()
+}
 
-	case termbox.KeyPgup:
-		cv.pageUp()
-	case termbox.KeyPgdn:
-		cv.pageDown()
-	case termbox.KeyArrowUp:
-		cv.up()
-	case termbox.KeyArrowDown:
-		cv.down()
-
-	case termbox.KeyCtrlK:
-		cv.ctrl.SelectChannel()
-
-	case termbox.KeyEnter:
-		// TODO: Should store this for upKey reedit scenario...
-		text := cv.editor.GetText()
-		cv.editor.Clear()
+// Sends the message, or runs it if it is a command
+func (cv *ChannelView) send() {
+	// TODO: Should store this for upKey reedit scenario...
+	text := cv.editor.GetText()
+	cv.editor.Clear()
+	cv.completions = nil
+	if strings.HasPrefix(text, "/") {
+		cv.ctrl.RunCommand(text)
+	} else {
 		cv.ctrl.SendMessage(text)
+	}
+}
 
-	// TODO: handle these better
-	case termbox.KeyArrowLeft, termbox.KeyCtrlB:
-		cv.editor.MoveCursorOneRuneBackward()
-		cv.ctrl.Redraw()
-	case termbox.KeyArrowRight, termbox.KeyCtrlF:
-		cv.editor.MoveCursorOneRuneForward()
-		cv.ctrl.Redraw()
-	case termbox.KeyBackspace, termbox.KeyBackspace2:
-		cv.editor.DeleteRuneBackward()
-		cv.ctrl.Redraw()
-	case termbox.KeyDelete, termbox.KeyCtrlD:
-		cv.editor.DeleteRuneForward()
-		cv.ctrl.Redraw()
-	case termbox.KeyTab:
-		cv.editor.InsertRune('\t')
-		cv.ctrl.Redraw()
-	case termbox.KeySpace:
-		cv.editor.InsertRune(' ')
-		cv.ctrl.Redraw()
-	case termbox.KeyHome, termbox.KeyCtrlA:
-		cv.editor.MoveCursorToBeginningOfTheLine()
-		cv.ctrl.Redraw()
-	case termbox.KeyEnd, termbox.KeyCtrlE:
-		cv.editor.MoveCursorToEndOfTheLine()
-		cv.ctrl.Redraw()
+// Completes the command being typed, otherwise inserts a tab
+func (cv *ChannelView) completeCommand() {
+	cv.edit(func(eb *EditBox) {
+		if text := eb.GetText(); strings.HasPrefix(text, "/") {
+			eb.SetText(cv.ctrl.CompleteCommand(text))
+		} else {
+			eb.InsertRune('\t')
+		}
+	})
+}
+
+// Hides completions until the word being typed changes
+func (cv *ChannelView) dismissCompletions() {
+	cv.dismissed = cv.completions.word
+	cv.completions = nil
+}
+
+// Moves the read cursor to before the selected message
+func (cv *ChannelView) markUnread() {
+	cv.ctrl.MarkUnread(cv.cl, cv.cl.pos)
+	cv.lastRead = cv.cl.lastRead
+	cv.ctrl.Redraw()
+}
+
+func (cv *ChannelView) acceptCompletion() {
+	cv.editor.ReplaceBeforeCursor(cv.completions.offset, cv.completions.current().text + " ")
+	cv.completions = nil
+}
+
+// Shows completions for the word being typed, if any
+func (cv *ChannelView) updateCompletions() {
+	word, offset := cv.editor.WordBeforeCursor()
+	switch {
+	case cv.completions != nil && cv.completions.word == word && cv.completions.offset == offset:
+		return // Unchanged
+	case word == cv.dismissed || !isCompletionTrigger(word, offset):
+		cv.completions = nil
+		return
+	}
+	cv.dismissed = ""
+	cv.completions = nil
+	if matches := cv.ctrl.Complete(word); len(matches) > 0 {
+		cv.completions = &CompletionPopup{ matches: matches, word: word, offset: offset }
+	}
+}
+
+// Shows & focuses the member panel, then hides it
+func (cv *ChannelView) toggleMembers() {
+	switch {
+	case cv.members == nil:
+		cv.members = NewMemberPanel(cv.ctrl, cv.cl, cv.cl.team.apis.GetUserList())
+	case cv.members.focused:
+		cv.members = nil
 	default:
-		cv.editor.InsertRune(r)
-		cv.ctrl.Redraw()
+		cv.members.focused = true
 	}
+	cv.ctrl.Redraw()
 }
 
 func (cv *ChannelView) pageUp() {
@@ -195,29 +650,39 @@ func (cv *ChannelView) scroll(inc int) {
 	if cv.cl.pos <= 30 {
 		cv.ctrl.LoadMessages(cv.cl)
 	}
+	cv.ctrl.MarkRead(cv.cl)
 	cv.ctrl.Redraw()
 }
 
 const coldef = termbox.ColorDefault
 const lineColour = termbox.ColorYellow
 
+const mentionColour = termbox.Attribute(209)
+
 const monoFg = termbox.Attribute(197)
 const monoBg = termbox.Attribute(239)
 
-func (cv *ChannelView) Draw(term Terminal) {
+// Draws the messages, member panel & editor in the region x0 <= x < w, y0 <= y < h
+func (cv *ChannelView) DrawIn(x0, y0, w, h int, focused bool, screen Terminal) {
 
 	msgs := cv.cl.msgs
 	msgPos := cv.cl.pos
-	term.Clear(coldef, coldef)
 
-	w, h := term.Size()
-	ui.Printf("Terminal (w:%v, h:%v)\n", w, h)
+	// Draw relative to the top of the pane, clipping anything above it
+	term := &clipTerminal{Terminal: screen, y0: y0, h: h-y0}
+	h -= y0
 
-	msgBoxHeight := h-4
-	printBorder(0, 0, w, msgBoxHeight, term)
+	// Message box spans x0 <= x < x1
+	msgBoxHeight := h-3
+	x1 := w
+	if cv.members != nil {
+		x1 = w - memberPanelWidth
+	}
+	msgBoxWidth := x1 - x0
+	printBorder(x0, 0, x1, msgBoxHeight, term)
 
 	var prev time.Time
-	x, y := 1, msgBoxHeight-1
+	x, y := x0+1, msgBoxHeight-1
 
 	for i := msgPos; len(msgs) > 0 && i >= 0; i-- {
 		msg := msgs[i]
@@ -225,28 +690,92 @@ func (cv *ChannelView) Draw(term Terminal) {
 		// Print separator if day changes
 		if i != len(msgs)-1 && prev.Day() > msg.T.Day() {
 			y -= 2
-			printString(buildSeparator(w, prev), x-1, y, lineColour, coldef, term)
+			printString(buildSeparator(msgBoxWidth, prev), x-1, y, lineColour, coldef, term)
 			y--
 		}
 		prev = msg.T
 
+		// Print "new messages" line after the last read message
+		if i != len(msgs)-1 && cv.lastRead != "" && tsAfter(msgs[i+1].Ts, cv.lastRead) && !tsAfter(msg.Ts, cv.lastRead) {
+			y--
+			printString(buildNewMessagesSeparator(msgBoxWidth), x-1, y, termbox.ColorRed, coldef, term)
+		}
+
 		// Calculate required lines
-		c := &canvas{w: w-2, h: h, term: &nullTerminal{}}
-		drawMessage(msg, c)
+		c := &canvas{w: msgBoxWidth-2, h: h, term: &nullTerminal{}}
+		drawMessage(msg, cv.timeFormat, c)
 		y -= c.Lines()
-		drawMessage(msg, &canvas{x0: x, x: x,  y: y, w: w-2, h: h, term: term})
+		drawMessage(msg, cv.timeFormat, &canvas{x0: x, x: x,  y: y, w: msgBoxWidth-2, h: h, term: term})
+
+		// Mark mentions in the gutter
+		if msg.IsMention {
+			for l := 0; l < c.Lines(); l++ {
+				term.SetCell('┃', x0, y+l, mentionColour, coldef)
+			}
+		}
+
+		// Mark selected message when scrolled back
+		if i == msgPos && msgPos != len(msgs)-1 {
+			term.SetCell('▶', x0, y, termbox.ColorWhite, coldef)
+		}
+	}
+
+	// Draw header over the top border
+	printBorder(x0, 0, x1, msgBoxHeight, term)
+	header := runewidth.Truncate(" " + channelHeader(cv.cl, time.Now()) + " ", msgBoxWidth-4, "… ")
+	headerFg := lineColour
+	if focused {
+		headerFg = termbox.ColorWhite
+	}
+	printString(header, x0+2, 0, headerFg, coldef, term)
+
+	if cv.members != nil {
+		cv.members.Draw(x1, w, msgBoxHeight, term)
 	}
 
 	// Draw input box
 
-	printBorder(0, msgBoxHeight, w, h-1, term)
-	cv.editor.Draw(1, msgBoxHeight+1, w-2, 1)
-	termbox.SetCursor(1+cv.editor.CursorX(), msgBoxHeight+1)
+	printBorder(x0, msgBoxHeight, w, h, term)
+	if typing := formatUsersTyping(cv.cl.usersTyping()); typing != "" {
+		printString(runewidth.Truncate(" " + typing + " ", w-x0-4, "… "), x0+2, h-1, coldef, coldef, term)
+	}
+	cv.editor.Draw(x0+1, y0+msgBoxHeight+1, w-x0-2, 1)
+	if !focused || (cv.members != nil && cv.members.focused) {
+		return
+	}
+	termbox.SetCursor(x0+1+cv.editor.CursorX(), y0+msgBoxHeight+1)
 
-	// Draw status bar
-	printString(formatUsersTyping(userTypingTimer.UsersTyping()), 1, h-1, coldef, coldef, term)
+	// Completions may overlap panes above
+	if cv.completions != nil {
+		cv.completions.Draw(x0+1+cv.editor.ColumnOf(cv.completions.offset), y0+msgBoxHeight, w, screen)
+	}
+}
 
-	term.Flush()
+// Describes the channel: its name, member count & topic or for IMs, the other user's local time
+func channelHeader(cl *Channel, now time.Time) string {
+	switch {
+	case cl.user != "":
+		users := cl.team.apis.GetUserList()
+		header := "@" + users.GetName(cl.user)
+		if offset, ok := users.GetTzOffset(cl.user); ok {
+			local := now.UTC().Add(time.Duration(offset) * time.Second)
+			header += fmt.Sprintf(" │ %v local time", local.Format("3:04 PM"))
+		}
+		return header
+	case cl.mpim:
+		return fmt.Sprintf("%v │ %v members", cl.name, cl.members)
+	default:
+		header := "#" + cl.name
+		if cl.members > 0 {
+			header += fmt.Sprintf(" │ %v members", cl.members)
+		}
+		if cl.topic != "" {
+			header += " │ " + html.UnescapeString(cl.topic)
+		} else if cl.purpose != "" {
+			header += " │ " + html.UnescapeString(cl.purpose)
+		}
+		return header
+	}
 }
 
 func RequiredLines(pos0, w int, msg []rune) int {
@@ -270,6 +799,36 @@ func formatUsersTyping(users []string) string {
 	}
 }
 
+// Prints our own presence right aligned to x & returns the starting position
+func printPresence(presence string, x, y int, term Terminal) int {
+	switch presence {
+	case "active":
+		printString("● active", x-8, y, termbox.Attribute(30), coldef, term)
+		return x-8
+	case "away":
+		printString("○ away", x-6, y, termbox.ColorWhite, coldef, term)
+		return x-6
+	}
+	return x
+}
+
+// Prints who we are signed in as & the current team, followed by any other teams with unread messages, right aligned
+// to x
+func printTeamBadges(cur *team, teams []*team, x, y int, term Terminal) {
+	badges := fmt.Sprintf("@%v [%v]", cur.selfName, cur.name)
+	for _, t := range teams {
+		if t != cur && t.unread() > 0 {
+			badges += fmt.Sprintf(" %v(%v)", t.name, t.unread())
+		}
+	}
+	printString(badges, x-runewidth.StringWidth(badges), y, termbox.ColorWhite, coldef, term)
+}
+
+func buildNewMessagesSeparator(w int) string {
+	const label = "new messages"
+	return fmt.Sprintf("├%v %v ─┤", strings.Repeat("─", w-5-len(label)), label)
+}
+
 func buildSeparator(w int, t time.Time) string {
 	ts := t.Format("Jan 2")
 	return fmt.Sprintf("├%v %v ─┤", strings.Repeat("─", w-5-len(ts)), ts)
@@ -287,6 +846,8 @@ func ColoursForFormat(rs []rune, f fmtType) (termbox.Attribute, termbox.Attribut
 		return termbox.ColorBlack, termbox.ColorYellow
 	case Emoji:
 		return termbox.Attribute(227), coldef
+	case CustomEmoji:
+		return termbox.Attribute(213), coldef
 	case Link:
 		return coldef | termbox.AttrUnderline, coldef
 	default:
@@ -311,10 +872,12 @@ func tsToTime(ts string) time.Time {
 	return time.Unix(i, 0)
 }
 
-func parseTimestamp(t time.Time) string {
-	ts := t.Format("3:04 PM") // Uses current locale/timezone by default
-	if len(ts) == 7 {
-		ts = " " + ts
+// Formats the time with the layout (see time.Format), right aligned to the widest hour so messages line up
+func parseTimestamp(t time.Time, layout string) string {
+	ts := t.Format(layout) // Uses current locale/timezone by default
+	widest := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC).Format(layout)
+	if n := runewidth.StringWidth(widest) - runewidth.StringWidth(ts); n > 0 {
+		ts = strings.Repeat(" ", n) + ts
 	}
 	return ts
 }
@@ -342,20 +905,32 @@ func printString(s string, x, y int, fg, bg termbox.Attribute, term Terminal) in
 	return x
 }
 
-func drawMessage(msg *Message, c Canvas) {
+func drawMessage(msg *Message, timeFormat string, c Canvas) {
 
 	// Print message prefix
-	c.Printsf(parseTimestamp(msg.T), coldef, coldef)
+	tsFg := coldef
+	if msg.IsMention {
+		tsFg = mentionColour
+	}
+	c.Printsf(parseTimestamp(msg.T, timeFormat), tsFg, coldef)
 	c.Move(1, 0)
-	c.Printsf(msg.User, getColour(msg.User), coldef)
+	name := msg.User
+	if msg.IsAction {
+		name = "* " + name
+	}
+	c.Printsf(name, getColour(msg.User), coldef)
 	c.Move(1, 0)
 	if msg.IsEdited {
 		c.Printsf("(edited)", termbox.ColorBlue, coldef)
 		c.Move(1, 0)
 	}
 
-	// Print message content
-	defFg := coldef
+	// Print message content, actions are italicised
+	var attr termbox.Attribute
+	if msg.IsAction {
+		attr = termbox.AttrCursive
+	}
+	defFg := coldef | attr
 	defBg := coldef
 	pos := 0
 	if len(msg.Formats) > 0 {
@@ -368,6 +943,7 @@ func drawMessage(msg *Message, c Canvas) {
 			// Get styling & determine colours
 			styledText := rs[format.Start():format.End()]
 			fg, bg := ColoursForFormat(styledText, format.Type())
+			fg |= attr
 
 			// Preformatted text has background across whole width
 			if format.Type() == Preformatted {
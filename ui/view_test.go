@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 	"reflect"
+	"strings"
+	"github.com/nsf/termbox-go"
 )
 
 func TestTypingMonitorAddAndRemove(t *testing.T) {
@@ -81,4 +83,177 @@ func TestTypingMonitorClear(t *testing.T) {
 		t.Errorf("Got '%v', Wanted: '%v'", got, want)
 	}
 
-}
\ No newline at end of file
+}
+func TestScrollWindow(t *testing.T) {
+	tests := []struct {
+		pos, offset, h, want int
+	}{
+		{0, 0, 10, 0},
+		{9, 0, 10, 0},
+		{10, 0, 10, 1},
+		{25, 3, 10, 16},
+		{2, 5, 10, 2},
+		{4, 0, 0, 4},
+	}
+
+	for _, data := range tests {
+		got := scrollWindow(data.pos, data.offset, data.h)
+		if got != data.want {
+			t.Errorf("scrollWindow(%v, %v, %v)\nGot : '%v'\nWant: '%v'", data.pos, data.offset, data.h, got, data.want)
+		}
+	}
+}
+
+func TestChannelHeader(t *testing.T) {
+	tm := &team{apis: &userApis{users: testUsers(t)}}
+	tests := []struct {
+		cl   *Channel
+		want string
+	}{
+		{&Channel{name: "alice", user: "U1", team: tm}, "@alice │ 1:30 AM local time"},
+		{&Channel{name: "bob", user: "U2", team: tm}, "@bob"},
+		{&Channel{name: "general"}, "#general"},
+		{&Channel{name: "general", members: 42}, "#general │ 42 members"},
+		{&Channel{name: "general", members: 42, topic: "Tea &amp; biscuits"}, "#general │ 42 members │ Tea & biscuits"},
+		{&Channel{name: "alice, bob", members: 3, mpim: true}, "alice, bob │ 3 members"},
+	}
+
+	for _, data := range tests {
+		got := channelHeader(data.cl, time.Date(2017, 1, 1, 12, 0, 0, 0, time.FixedZone("PST", -8*60*60)))
+		if got != data.want {
+			t.Errorf("Got '%v', Wanted: '%v'", got, data.want)
+		}
+	}
+}
+
+// Records the cells written to it
+type cellTerminal struct {
+	nullTerminal
+	cells map[int]termbox.Cell // x + y*1000
+}
+
+func (t *cellTerminal) SetCell(r rune, x, y int, fg, bg termbox.Attribute) int {
+	t.cells[x+y*1000] = termbox.Cell{Ch: r, Fg: fg, Bg: bg}
+	return t.nullTerminal.SetCell(r, x, y, fg, bg)
+}
+
+func (t *cellTerminal) line(y, w int) string {
+	var rs []rune
+	for x := 0; x < w; x++ {
+		if c, ok := t.cells[x+y*1000]; ok {
+			rs = append(rs, c.Ch)
+		} else {
+			rs = append(rs, ' ')
+		}
+	}
+	return strings.TrimRight(string(rs), " ")
+}
+
+func TestDrawActionMessage(t *testing.T) {
+	term := &cellTerminal{cells: make(map[int]termbox.Cell)}
+	c := &canvas{w: 40, h: 1, term: term}
+	msg := &Message{User: "bob", Text: "waves", T: time.Date(2017, 1, 1, 9, 5, 0, 0, time.Local), IsAction: true}
+	drawMessage(msg, DefaultTimeFormat, c)
+	c.Flush()
+
+	want := " 9:05 AM * bob waves"
+	if got := term.line(0, 40); got != want {
+		t.Errorf("Got : '%v'\nWant: '%v'", got, want)
+	}
+	if fg := term.cells[len(want)-1].Fg; fg&termbox.AttrCursive == 0 {
+		t.Errorf("Action text not italic: %v", fg)
+	}
+}
+
+func TestChannelSelectionMatch(t *testing.T) {
+	now := time.Now()
+	chls := &ChannelList{}
+	for _, cl := range []*Channel{
+		{id: "C1", name: "general", viewed: now.Add(-time.Hour)},
+		{id: "C2", name: "random", viewed: now},
+		{id: "C3", name: "dev-ops", unread: 3},
+		{id: "C4", name: "devs", viewed: now.Add(-time.Minute)},
+	} {
+		chls.add(cl)
+	}
+	csv := NewChannelListView(nil, chls, nil, "team")
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"dev-ops", "random", "devs", "general"}},
+		{"dev", []string{"dev-ops", "devs"}},
+		{"do", []string{"dev-ops", "random"}},
+		{"ops", []string{"dev-ops"}},
+		{"xyz", []string{}},
+	}
+	for _, data := range tests {
+		csv.reset()
+		csv.filter = append(csv.filter, []rune(data.filter)...)
+		csv.match()
+		got := []string{}
+		for _, cl := range csv.matches {
+			got = append(got, cl.name)
+		}
+		if !reflect.DeepEqual(got, data.want) {
+			t.Errorf("match(%q)\nGot : %q\nWant: %q", data.filter, got, data.want)
+		}
+	}
+}
+
+func TestChannelRecency(t *testing.T) {
+	viewed := time.Unix(1500000000, 0)
+	cl := &Channel{viewed: viewed, lastRead: "1400000000.000100"}
+	if got := cl.recency(); !got.Equal(viewed) {
+		t.Errorf("Got : '%v'\nWant: '%v'", got, viewed)
+	}
+	cl.lastRead = "1600000000.000100"
+	if got := cl.recency(); !got.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("Got : '%v'\nWant: '%v'", got, time.Unix(1600000000, 0))
+	}
+	cl.msgs = append(cl.msgs, &Message{T: time.Unix(1700000000, 0)})
+	if got := cl.recency(); !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Got : '%v'\nWant: '%v'", got, time.Unix(1700000000, 0))
+	}
+}
+
+func TestTeamBadges(t *testing.T) {
+	quiet := &team{name: "quiet", chls: &ChannelList{}}
+	busy := &team{name: "busy", chls: &ChannelList{chls: []*Channel{{unread: 3}}}}
+	cur := &team{name: "acme", selfName: "gdx", chls: &ChannelList{chls: []*Channel{{unread: 1}}}}
+
+	var tests = []struct {
+		teams []*team
+		want  string
+	}{
+		{[]*team{cur}, "@gdx [acme]"},
+		{[]*team{cur, quiet}, "@gdx [acme]"},
+		{[]*team{quiet, cur, busy}, "@gdx [acme] busy(3)"},
+	}
+	for _, test := range tests {
+		term := &cellTerminal{cells: make(map[int]termbox.Cell)}
+		printTeamBadges(cur, test.teams, 40, 0, term)
+		if got := strings.TrimSpace(term.line(0, 40)); got != test.want {
+			t.Errorf("Got : %q\nWant: %q", got, test.want)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		t      time.Time
+		layout string
+		want   string
+	}{
+		{time.Date(2017, 1, 1, 9, 5, 0, 0, time.Local), "3:04 PM", " 9:05 AM"},
+		{time.Date(2017, 1, 1, 21, 5, 0, 0, time.Local), "3:04 PM", " 9:05 PM"},
+		{time.Date(2017, 1, 1, 9, 5, 0, 0, time.Local), "15:04", "09:05"},
+		{time.Date(2017, 1, 1, 9, 5, 7, 0, time.Local), "3:04:05", " 9:05:07"},
+	}
+	for _, test := range tests {
+		if got := parseTimestamp(test.t, test.layout); got != test.want {
+			t.Errorf("Got : %q\nWant: %q", got, test.want)
+		}
+	}
+}